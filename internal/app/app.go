@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/grpcserver"
 	"mzhn/auth/internal/handlers"
 	"mzhn/auth/internal/services/authservice"
 
@@ -16,20 +20,23 @@ import (
 
 	"github.com/labstack/echo/v4"
 	emw "github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	app *echo.Echo
-	cfg *config.Config
+	app  *echo.Echo
+	grpc *grpc.Server
+	cfg  *config.Config
 
 	as *authservice.AuthService
 }
 
 func newApp(cfg *config.Config, as *authservice.AuthService) *App {
 	return &App{
-		app: echo.New(),
-		cfg: cfg,
-		as:  as,
+		app:  echo.New(),
+		grpc: grpcserver.New(as),
+		cfg:  cfg,
+		as:   as,
 	}
 }
 
@@ -44,12 +51,86 @@ func (a *App) initApp() {
 
 	tokguard := mw.Token
 	authguard := mw.RequireAuth(a.as, a.cfg)
+	adminguard := mw.RequireGlobalAuth(a.as, a.cfg)
+
+	a.app.GET("/.well-known/jwks.json", handlers.JWKS(a.as))
+	a.app.GET("/.well-known/openid-configuration", handlers.Discovery(a.as))
+
+	csrfguard := mw.RequireCSRF("/authorize")
+
+	a.app.GET("/authorize", handlers.AuthorizeForm(a.as), csrfguard)
+	a.app.POST("/authorize", handlers.Authorize(a.as), csrfguard)
+	a.app.POST("/token", handlers.Token(a.as))
+	a.app.POST("/introspect", handlers.Introspect(a.as))
+	a.app.POST("/revoke", handlers.Revoke(a.as))
+	a.app.GET("/userinfo", handlers.Userinfo(a.as), tokguard(), authguard())
+	a.app.POST("/userinfo", handlers.Userinfo(a.as), tokguard(), authguard())
 
 	a.app.POST("/register", handlers.Register(a.as))
 	a.app.POST("/login", handlers.Login(a.as))
+	a.app.POST("/login/mfa", handlers.VerifyMfa(a.as))
+	a.app.POST("/login/passkey/begin", handlers.BeginPasskeyLogin(a.as))
+	a.app.POST("/login/passkey", handlers.PasskeyLogin(a.as))
+	a.app.POST("/verify-email", handlers.VerifyEmail(a.as))
+	a.app.POST("/verify-email/resend", handlers.ResendVerification(a.as))
+	a.app.POST("/password/forgot", handlers.ForgotPassword(a.as))
+	a.app.POST("/password/reset", handlers.ResetPassword(a.as))
 	a.app.POST("/refresh", handlers.Refresh(a.as), tokguard())
 	a.app.GET("/profile", handlers.Profile(a.as), tokguard(), authguard())
+	a.app.PATCH("/profile", handlers.UpdateProfile(a.as), tokguard(), authguard())
+	a.app.POST("/profile/email", handlers.ChangeEmail(a.as), tokguard(), authguard())
+	a.app.POST("/profile/email/confirm", handlers.ConfirmEmailChange(a.as))
+	a.app.DELETE("/profile", handlers.DeleteAccount(a.as), tokguard(), authguard())
+	a.app.Any("/auth", handlers.Auth(a.as, a.cfg), tokguard())
 	a.app.POST("/logout", handlers.Logout(a.as), tokguard(), authguard())
+
+	a.app.GET("/sessions", handlers.Sessions(a.as), tokguard(), authguard())
+	a.app.DELETE("/sessions", handlers.RevokeOtherSessions(a.as), tokguard(), authguard())
+	a.app.DELETE("/sessions/:id", handlers.RevokeSession(a.as), tokguard(), authguard())
+	a.app.POST("/password/change", handlers.ChangePassword(a.as), tokguard(), authguard())
+
+	a.app.POST("/mfa/totp", handlers.EnrollTotp(a.as), tokguard(), authguard())
+	a.app.POST("/mfa/totp/confirm", handlers.ConfirmTotp(a.as), tokguard(), authguard())
+	a.app.DELETE("/mfa/totp", handlers.DisableTotp(a.as), tokguard(), authguard())
+	a.app.GET("/mfa/recovery-codes", handlers.RecoveryCodesLeft(a.as), tokguard(), authguard())
+	a.app.POST("/mfa/recovery-codes", handlers.RegenerateRecoveryCodes(a.as), tokguard(), authguard())
+
+	a.app.GET("/passkeys", handlers.Passkeys(a.as), tokguard(), authguard())
+	a.app.POST("/passkeys/register/begin", handlers.BeginPasskeyRegistration(a.as), tokguard(), authguard())
+	a.app.POST("/passkeys/register", handlers.FinishPasskeyRegistration(a.as), tokguard(), authguard())
+	a.app.DELETE("/passkeys/:id", handlers.DeletePasskey(a.as), tokguard(), authguard())
+
+	a.app.POST("/organizations", handlers.CreateOrganization(a.as), tokguard(), authguard())
+	a.app.GET("/organizations", handlers.Organizations(a.as), tokguard(), authguard())
+	a.app.POST("/organizations/switch", handlers.SwitchOrganization(a.as), tokguard())
+	a.app.GET("/organizations/:org/members", handlers.Members(a.as), tokguard(), authguard())
+	a.app.POST("/organizations/:org/members", handlers.AddMember(a.as), tokguard(), authguard())
+	a.app.DELETE("/organizations/:org/members/:id", handlers.RemoveMember(a.as), tokguard(), authguard())
+	a.app.POST("/organizations/:org/members/:id/roles", handlers.GrantRoles(a.as), tokguard(), authguard())
+	a.app.DELETE("/organizations/:org/members/:id/roles/:role", handlers.RevokeRole(a.as), tokguard(), authguard())
+
+	a.app.GET("/clients", handlers.Clients(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/clients", handlers.CreateClient(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.DELETE("/clients/:id", handlers.DeleteClient(a.as), tokguard(), adminguard(entity.RoleAdmin))
+
+	a.app.GET("/roles", handlers.Roles(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/roles", handlers.CreateRole(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.GET("/roles/:name", handlers.Role(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.PATCH("/roles/:name", handlers.UpdateRole(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.DELETE("/roles/:name", handlers.DeleteRole(a.as), tokguard(), adminguard(entity.RoleAdmin))
+
+	a.app.GET("/users/:id/roles", handlers.UserRoles(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/users/:id/roles", handlers.GrantRoles(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.DELETE("/users/:id/roles/:role", handlers.RevokeRole(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.GET("/users/:id/roles/audit", handlers.RoleAudit(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/users/:id/disable", handlers.DisableUser(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/users/:id/enable", handlers.EnableUser(a.as), tokguard(), adminguard(entity.RoleAdmin))
+
+	a.app.POST("/invites", handlers.CreateInvite(a.as), tokguard(), adminguard(entity.RoleAdmin))
+
+	a.app.GET("/permissions", handlers.Permissions(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.POST("/permissions", handlers.CreatePermission(a.as), tokguard(), adminguard(entity.RoleAdmin))
+	a.app.DELETE("/permissions/:name", handlers.DeletePermission(a.as), tokguard(), adminguard(entity.RoleAdmin))
 }
 
 func (a *App) Run() {
@@ -70,7 +151,44 @@ func (a *App) Run() {
 		a.app.Start(addr)
 	}()
 
+	go func() {
+		addr := fmt.Sprintf(":%d", a.cfg.Grpc.Port)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			slog.Error("cannot listen grpc", slog.String("addr", addr), slog.String("err", err.Error()))
+			return
+		}
+
+		slog.Info("running grpc server", slog.String("addr", addr))
+		a.grpc.Serve(lis)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.purge(ctx)
+
 	sig := <-sigChan
 	slog.Info(fmt.Sprintf("Signal %v received, stopping server...\n", sig))
+	cancel()
+	a.grpc.GracefulStop()
 	a.app.Shutdown(context.Background())
 }
+
+// purge removes deleted accounts once their retention window has passed.
+func (a *App) purge(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(a.cfg.Accounts.PurgeInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.as.PurgeDeletedUsers(ctx); err != nil {
+			slog.Error("cannot purge deleted users", slog.String("err", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
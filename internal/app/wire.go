@@ -5,10 +5,14 @@ package app
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log/slog"
 
 	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/lib/secret"
+	"mzhn/auth/internal/mailer"
 	"mzhn/auth/internal/services/authservice"
 	"mzhn/auth/internal/storage/pg"
 
@@ -25,17 +29,40 @@ func New() (*App, func(), error) {
 		newApp,
 		pg.NewUserStorage,
 		pg.NewRoleStorage,
+		pg.NewPermissionStorage,
+		pg.NewOrganizationStorage,
+		pg.NewClientStorage,
+		pg.NewRecoveryCodeStorage,
+		pg.NewPasskeyStorage,
 		rd.NewSessionsStorage,
+		rd.NewEventsStorage,
+		rd.NewRevocationStorage,
+		rd.NewAuthCodeStorage,
+		rd.NewInviteStorage,
+		rd.NewOneTimeTokenStorage,
 
 		authservice.New,
 
 		initPG,
 		initRedis,
+		initKeyring,
+		initMailer,
+		initSecretBox,
 		config.New,
 
 		wire.Bind(new(authservice.RoleStorage), new(*pg.RoleStorage)),
+		wire.Bind(new(authservice.PermissionStorage), new(*pg.PermissionStorage)),
+		wire.Bind(new(authservice.OrganizationStorage), new(*pg.OrganizationStorage)),
 		wire.Bind(new(authservice.UserStorage), new(*pg.UsersStorage)),
+		wire.Bind(new(authservice.ClientStorage), new(*pg.ClientStorage)),
+		wire.Bind(new(authservice.RecoveryCodeStorage), new(*pg.RecoveryCodeStorage)),
+		wire.Bind(new(authservice.PasskeyStorage), new(*pg.PasskeyStorage)),
 		wire.Bind(new(authservice.SessionsStorage), new(*rd.SessionsStorage)),
+		wire.Bind(new(authservice.EventsStorage), new(*rd.EventsStorage)),
+		wire.Bind(new(authservice.RevocationStorage), new(*rd.RevocationStorage)),
+		wire.Bind(new(authservice.AuthCodeStorage), new(*rd.AuthCodeStorage)),
+		wire.Bind(new(authservice.InviteStorage), new(*rd.InviteStorage)),
+		wire.Bind(new(authservice.OneTimeTokenStorage), new(*rd.OneTimeTokenStorage)),
 	))
 }
 
@@ -93,3 +120,88 @@ func initRedis(cfg *config.Config) (*redis.Client, func(), error) {
 		client.Close()
 	}, nil
 }
+
+func initMailer(cfg *config.Config) (authservice.Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		slog.Info("using smtp mailer", slog.String("host", cfg.Mail.SmtpHost), slog.Int("port", cfg.Mail.SmtpPort))
+		return mailer.NewSMTPMailer(cfg)
+	case "log":
+		slog.Info("using log mailer", slog.String("path", cfg.Mail.LogPath))
+		return mailer.NewLogMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", cfg.Mail.Driver)
+	}
+}
+
+func initSecretBox(cfg *config.Config) (*secret.Box, error) {
+	if cfg.Mfa.EncryptionKey == "" {
+		slog.Warn("MFA_ENCRYPTION_KEY is not set, TOTP enrollment is disabled")
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.Mfa.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("MFA_ENCRYPTION_KEY must be base64 encoded: %w", err)
+	}
+
+	return secret.NewBox(key)
+}
+
+func initKeyring(cfg *config.Config) (*jwt.Keyring, error) {
+	if cfg.Jwt.KeyringPath != "" {
+		keyring, err := jwt.LoadKeyring(cfg.Jwt.KeyringPath)
+		if err != nil {
+			slog.Error("failed to load keyring", slog.String("err", err.Error()), slog.String("path", cfg.Jwt.KeyringPath))
+			return nil, err
+		}
+
+		active, err := keyring.Active()
+		if err != nil {
+			slog.Error("keyring has no active key", slog.String("path", cfg.Jwt.KeyringPath))
+			return nil, err
+		}
+
+		slog.Info("loaded keyring", slog.String("path", cfg.Jwt.KeyringPath), slog.String("active", active.Id))
+
+		if _, err := keyring.ActiveAsymmetric(); err != nil {
+			slog.Warn("keyring has no active asymmetric key, OpenID Connect ID tokens cannot be issued", slog.String("path", cfg.Jwt.KeyringPath))
+		}
+
+		return keyring, nil
+	}
+
+	key, err := initAccessKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Symmetric() {
+		slog.Warn("access key is symmetric, OpenID Connect ID tokens cannot be issued", slog.String("alg", cfg.Jwt.AccessAlg))
+	}
+
+	return jwt.NewKeyring(&jwt.RingKey{Key: key})
+}
+
+func initAccessKey(cfg *config.Config) (*jwt.Key, error) {
+	if cfg.Jwt.AccessAlg == jwt.AlgHS256 {
+		if cfg.Jwt.AccessSecret == "" {
+			return nil, fmt.Errorf("JWT_ACCESS_SECRET is required for %s", jwt.AlgHS256)
+		}
+		return jwt.NewHMACKey(cfg.Jwt.AccessKeyId, []byte(cfg.Jwt.AccessSecret)), nil
+	}
+
+	if cfg.Jwt.AccessKeyPath == "" {
+		return nil, fmt.Errorf("JWT_ACCESS_KEY_PATH is required for %s", cfg.Jwt.AccessAlg)
+	}
+
+	key, err := jwt.LoadKey(cfg.Jwt.AccessKeyId, cfg.Jwt.AccessAlg, cfg.Jwt.AccessKeyPath)
+	if err != nil {
+		slog.Error("failed to load access key", slog.String("err", err.Error()), slog.String("path", cfg.Jwt.AccessKeyPath))
+		return nil, err
+	}
+
+	slog.Info("loaded access key", slog.String("kid", key.Id), slog.String("alg", cfg.Jwt.AccessAlg))
+
+	return key, nil
+}
@@ -18,6 +18,10 @@ type App struct {
 	Port    int    `env:"APP_PORT" env-required:"true"`
 }
 
+type Grpc struct {
+	Port int `env:"GRPC_PORT" env-default:"7002"`
+}
+
 type Pg struct {
 	Host string `env:"PG_HOST" env-required:"true"`
 	Port int    `env:"PG_PORT" env-required:"true"`
@@ -33,10 +37,67 @@ type Redis struct {
 }
 
 type Jwt struct {
-	AccessSecret  string `env:"JWT_ACCESS_SECRET" env-required:"true"`
-	AccessTTL     int    `env:"JWT_ACCESS_TTL" env-required:"true"`
-	RefreshSecret string `env:"JWT_REFRESH_SECRET" env-required:"true"`
-	RefreshTTL    int    `env:"JWT_REFRESH_TTL" env-required:"true"`
+	AccessAlg     string   `env:"JWT_ACCESS_ALG" env-default:"HS256"`
+	AccessKeyId   string   `env:"JWT_ACCESS_KID"`
+	AccessKeyPath string   `env:"JWT_ACCESS_KEY_PATH"`
+	AccessSecret  string   `env:"JWT_ACCESS_SECRET"`
+	KeyringPath   string   `env:"JWT_KEYRING_PATH"`
+	Audience      []string `env:"JWT_AUDIENCE" env-separator:","`
+	AccessTTL     int      `env:"JWT_ACCESS_TTL" env-required:"true"`
+	RefreshSecret string   `env:"JWT_REFRESH_SECRET" env-required:"true"`
+	RefreshTTL    int      `env:"JWT_REFRESH_TTL" env-required:"true"`
+	Stateless     bool     `env:"JWT_STATELESS"`
+}
+
+type Oidc struct {
+	Issuer  string `env:"OIDC_ISSUER"`
+	CodeTTL int    `env:"OIDC_CODE_TTL" env-default:"60"`
+}
+
+type Registration struct {
+	DefaultRole          string `env:"REGISTRATION_DEFAULT_ROLE" env-default:"regular"`
+	InviteTTL            int    `env:"REGISTRATION_INVITE_TTL" env-default:"10080"`
+	RequireVerifiedEmail bool   `env:"REGISTRATION_REQUIRE_VERIFIED_EMAIL"`
+	VerificationTTL      int    `env:"REGISTRATION_VERIFICATION_TTL" env-default:"1440"`
+	VerificationUrl      string `env:"REGISTRATION_VERIFICATION_URL" env-default:"http://localhost:3000/verify-email"`
+}
+
+type Webauthn struct {
+	RpId    string   `env:"WEBAUTHN_RP_ID" env-default:"localhost"`
+	RpName  string   `env:"WEBAUTHN_RP_NAME"`
+	Origins []string `env:"WEBAUTHN_ORIGINS" env-separator:"," env-default:"http://localhost:3000"`
+	Timeout int      `env:"WEBAUTHN_TIMEOUT" env-default:"300"`
+}
+
+type Mfa struct {
+	EncryptionKey    string `env:"MFA_ENCRYPTION_KEY"`
+	Issuer           string `env:"MFA_ISSUER"`
+	ChallengeTTL     int    `env:"MFA_CHALLENGE_TTL" env-default:"5"`
+	EnforceForAdmins bool   `env:"MFA_ENFORCE_FOR_ADMINS" env-default:"true"`
+}
+
+type Accounts struct {
+	Retention     int `env:"ACCOUNTS_RETENTION" env-default:"43200"`
+	PurgeInterval int `env:"ACCOUNTS_PURGE_INTERVAL" env-default:"60"`
+}
+
+type Profile struct {
+	EmailChangeUrl string `env:"PROFILE_EMAIL_CHANGE_URL" env-default:"http://localhost:3000/profile/email/confirm"`
+}
+
+type Password struct {
+	ResetTTL int    `env:"PASSWORD_RESET_TTL" env-default:"60"`
+	ResetUrl string `env:"PASSWORD_RESET_URL" env-default:"http://localhost:3000/password/reset"`
+}
+
+type Mail struct {
+	Driver   string `env:"MAIL_DRIVER" env-default:"log"`
+	From     string `env:"MAIL_FROM" env-default:"no-reply@localhost"`
+	SmtpHost string `env:"MAIL_SMTP_HOST"`
+	SmtpPort int    `env:"MAIL_SMTP_PORT" env-default:"587"`
+	SmtpUser string `env:"MAIL_SMTP_USER"`
+	SmtpPass string `env:"MAIL_SMTP_PASS"`
+	LogPath  string `env:"MAIL_LOG_PATH"`
 }
 
 type Bcrypt struct {
@@ -44,12 +105,21 @@ type Bcrypt struct {
 }
 
 type Config struct {
-	Env    string `env:"ENV" env-default:"local"`
-	App    App
-	Pg     Pg
-	Jwt    Jwt
-	Bcrypt Bcrypt
-	Redis  Redis
+	Env          string `env:"ENV" env-default:"local"`
+	App          App
+	Grpc         Grpc
+	Pg           Pg
+	Jwt          Jwt
+	Oidc         Oidc
+	Registration Registration
+	Password     Password
+	Profile      Profile
+	Accounts     Accounts
+	Mfa          Mfa
+	Webauthn     Webauthn
+	Mail         Mail
+	Bcrypt       Bcrypt
+	Redis        Redis
 }
 
 func New() *Config {
@@ -0,0 +1,11 @@
+package dto
+
+type DisableUser struct {
+	UserId  string
+	ActorId string
+}
+
+type DeleteAccount struct {
+	UserId   string
+	Password string
+}
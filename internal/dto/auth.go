@@ -5,15 +5,21 @@ import "mzhn/auth/internal/entity"
 type Authenticate struct {
 	AccessToken string
 	Roles       []entity.Role
+	Permissions []entity.Permission
+	// Global requires the roles and permissions to be held outside of any organization
+	Global bool
 }
 
 type Login struct {
 	Email    string
 	Password string
+	Audience string
+	Device   Device
 }
 
 type Refresh struct {
 	RefreshToken string
+	Device       Device
 }
 
 type Tokens struct {
@@ -0,0 +1,7 @@
+package dto
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// MfaChallenge is returned by Login instead of tokens when the user has a second factor enrolled.
+type MfaChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type VerifyMfa struct {
+	Token    string
+	Code     string
+	Audience string
+	Device   Device
+}
+
+type TotpEnrollment struct {
+	Secret string
+	Uri    string
+}
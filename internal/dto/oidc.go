@@ -0,0 +1,76 @@
+package dto
+
+import "mzhn/auth/internal/entity"
+
+type AuthorizeParams struct {
+	ResponseType        string
+	ClientId            string
+	RedirectUri         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+type Authorize struct {
+	AuthorizeParams
+	Email    string
+	Password string
+	// Otp is the second factor code, required when the user has one enrolled
+	Otp string
+}
+
+type Token struct {
+	GrantType    string
+	Code         string
+	RedirectUri  string
+	ClientId     string
+	ClientSecret string
+	Scope        string
+	CodeVerifier string
+	RefreshToken string
+	Device       Device
+}
+
+type TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	IdToken      string
+	ExpiresIn    int
+	Scope        string
+}
+
+type Discovery struct {
+	Issuer      string
+	SigningAlgs []string
+}
+
+type CreateClient struct {
+	Name         string
+	RedirectUris []string
+	Scopes       []string
+	GrantTypes   []string
+	Confidential bool
+}
+
+type IntrospectToken struct {
+	Token         string
+	TokenTypeHint string
+	ClientId      string
+	ClientSecret  string
+}
+
+type Introspection struct {
+	Active    bool
+	TokenType string
+	Issuer    string
+	Claims    *entity.UserClaims
+}
+
+type RevokeToken struct {
+	Token         string
+	TokenTypeHint string
+	ClientId      string
+	ClientSecret  string
+}
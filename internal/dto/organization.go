@@ -0,0 +1,29 @@
+package dto
+
+import "mzhn/auth/internal/entity"
+
+type CreateOrganization struct {
+	Name    string
+	Slug    string
+	OwnerId string
+}
+
+type AddMember struct {
+	OrganizationId string
+	UserId         string
+	ActorId        string
+	Roles          []entity.Role
+}
+
+type RemoveMember struct {
+	OrganizationId string
+	UserId         string
+	ActorId        string
+}
+
+// SwitchOrganization re-issues the session tokens for the organization, an empty id switches to the personal context.
+type SwitchOrganization struct {
+	RefreshToken   string
+	OrganizationId string
+	Device         Device
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "mzhn/auth/internal/lib/webauthn"
+
+type BeginPasskeyRegistration struct {
+	UserId   string
+	Password string
+	Code     string
+}
+
+type RegisterPasskey struct {
+	UserId     string
+	Name       *string
+	Credential *webauthn.RegistrationCredential
+}
+
+type CreatePasskey struct {
+	Id        []byte
+	UserId    string
+	PublicKey []byte
+	SignCount int64
+	Name      *string
+}
+
+type PasskeyLogin struct {
+	Credential *webauthn.AssertionCredential
+	Audience   string
+	Device     Device
+}
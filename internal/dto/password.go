@@ -0,0 +1,13 @@
+package dto
+
+type ResetPassword struct {
+	Token    string
+	Password string
+}
+
+type ChangePassword struct {
+	UserId          string
+	SessionId       string
+	CurrentPassword string
+	NewPassword     string
+}
@@ -0,0 +1,14 @@
+package dto
+
+type UpdateProfile struct {
+	UserId     string
+	LastName   *string
+	FirstName  *string
+	MiddleName *string
+}
+
+type ChangeEmail struct {
+	UserId   string
+	Email    string
+	Password string
+}
@@ -2,17 +2,57 @@ package dto
 
 import "mzhn/auth/internal/entity"
 
+// AddRoles grants roles on behalf of ActorId, empty for the system.
+// Roles are global unless OrganizationId is set.
 type AddRoles struct {
-	UserId string
-	Roles  []entity.Role
+	UserId         string
+	ActorId        string
+	OrganizationId string
+	Roles          []entity.Role
 }
 
+// RemoveRoles revokes roles on behalf of ActorId, empty for the system.
+// Roles are global unless OrganizationId is set.
 type RemoveRoles struct {
-	UserId string
-	Roles  []entity.Role
+	UserId         string
+	ActorId        string
+	OrganizationId string
+	Roles          []entity.Role
 }
 
+// CheckRoles looks at global roles and, when OrganizationId is set, the roles in that organization.
 type CheckRoles struct {
-	UserId string
-	Roles  []entity.Role
+	UserId         string
+	OrganizationId string
+	Roles          []entity.Role
+}
+
+type CheckPermissions struct {
+	UserId         string
+	OrganizationId string
+	Permissions    []entity.Permission
+}
+
+type CreateRole struct {
+	Name        entity.Role
+	Description *string
+	Permissions []entity.Permission
+}
+
+// UpdateRole changes only the fields that are set, nil Permissions keeps the current ones.
+type UpdateRole struct {
+	Name        entity.Role
+	Description *string
+	Permissions []entity.Permission
+}
+
+type CreatePermission struct {
+	Name        entity.Permission
+	Description *string
+}
+
+type CreateInvite struct {
+	Email     *string
+	Roles     []entity.Role
+	CreatedBy string
 }
@@ -0,0 +1,6 @@
+package dto
+
+type Device struct {
+	UserAgent string
+	Ip        string
+}
@@ -1,6 +1,16 @@
 package dto
 
-import "mzhn/auth/internal/entity"
+import "time"
+
+// UpdateUser changes only the fields that are set, Password is expected to be hashed.
+type UpdateUser struct {
+	LastName        *string
+	FirstName       *string
+	MiddleName      *string
+	Email           *string
+	Password        *string
+	EmailVerifiedAt *time.Time
+}
 
 type CreateUser struct {
 	LastName   *string
@@ -8,5 +18,7 @@ type CreateUser struct {
 	MiddleName *string
 	Email      string
 	Password   string
-	Roles      []entity.Role
+	Invite     string
+	Audience   string
+	Device     Device
 }
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type RoleAuditAction string
+
+const (
+	RoleAuditGrant  RoleAuditAction = "grant"
+	RoleAuditRevoke RoleAuditAction = "revoke"
+)
+
+// RoleAudit records a role change of a user. ActorId is nil for changes made by the system,
+// OrganizationId is nil for global roles and is kept after the organization is deleted.
+type RoleAudit struct {
+	Id             int64           `json:"id" db:"id"`
+	UserId         string          `json:"userId" db:"user_id"`
+	ActorId        *string         `json:"actorId" db:"actor_id"`
+	OrganizationId *string         `json:"organizationId" db:"organization_id"`
+	Action         RoleAuditAction `json:"action" db:"action"`
+	Role           Role            `json:"role" db:"role"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type AuthCode struct {
+	ClientId            string    `json:"clientId"`
+	RedirectUri         string    `json:"redirectUri"`
+	UserId              string    `json:"userId"`
+	Scope               []string  `json:"scope"`
+	Nonce               string    `json:"nonce"`
+	CodeChallenge       string    `json:"codeChallenge"`
+	CodeChallengeMethod string    `json:"codeChallengeMethod"`
+	AuthTime            time.Time `json:"authTime"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+type Client struct {
+	Id           string     `json:"id"`
+	Name         string     `json:"name"`
+	HashedSecret *string    `json:"-"`
+	RedirectUris []string   `json:"redirectUris"`
+	Scopes       []string   `json:"scopes"`
+	GrantTypes   []string   `json:"grantTypes"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    *time.Time `json:"updatedAt"`
+}
+
+// Confidential reports whether the client authenticates with a secret.
+func (c *Client) Confidential() bool {
+	return c.HashedSecret != nil
+}
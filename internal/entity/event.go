@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+type SecurityEventType string
+
+const (
+	EventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+)
+
+type SecurityEvent struct {
+	Type      SecurityEventType `json:"type"`
+	UserId    string            `json:"userId"`
+	SessionId string            `json:"sessionId"`
+	Ip        string            `json:"ip"`
+	UserAgent string            `json:"userAgent"`
+	At        time.Time         `json:"at"`
+}
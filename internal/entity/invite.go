@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Invite lets its holder register with roles approved in advance by an admin.
+// When Email is set only that address can redeem it.
+type Invite struct {
+	Email     *string   `json:"email"`
+	Roles     []Role    `json:"roles"`
+	CreatedBy string    `json:"createdBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
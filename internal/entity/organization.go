@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+type Organization struct {
+	Id        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Slug      string     `json:"slug" db:"slug"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Member is a user belonging to an organization with the roles held in it.
+type Member struct {
+	UserId   string    `json:"userId" db:"user_id"`
+	Email    string    `json:"email" db:"email"`
+	Roles    []Role    `json:"roles" db:"-"`
+	JoinedAt time.Time `json:"joinedAt" db:"created_at"`
+}
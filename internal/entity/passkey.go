@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Passkey struct {
+	Id         []byte     `json:"id" db:"id"`
+	UserId     string     `json:"userId" db:"user_id"`
+	PublicKey  []byte     `json:"-" db:"public_key"`
+	SignCount  int64      `json:"-" db:"sign_count"`
+	Name       *string    `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" db:"last_used_at"`
+}
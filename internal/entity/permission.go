@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type Permission string
+
+func (p Permission) String() string {
+	return string(p)
+}
+
+type PermissionDefinition struct {
+	Name        Permission `json:"name" db:"name"`
+	Description *string    `json:"description" db:"description"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
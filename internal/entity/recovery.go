@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+type RecoveryCode struct {
+	Id        int64      `json:"id" db:"id"`
+	UserId    string     `json:"userId" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UsedAt    *time.Time `json:"usedAt" db:"used_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
@@ -1,7 +1,10 @@
 package entity
 
+import "time"
+
 type Role string
 
+// Built-in roles, seeded by migrations and protected from deletion.
 const (
 	RoleAdmin   Role = "admin"
 	RoleSupport Role = "support"
@@ -12,6 +15,14 @@ func (r Role) String() string {
 	return string(r)
 }
 
-func (r Role) Valid() bool {
+func (r Role) Builtin() bool {
 	return r == RoleAdmin || r == RoleSupport || r == RoleRegular
 }
+
+type RoleDefinition struct {
+	Name        Role         `json:"name" db:"name"`
+	Description *string      `json:"description" db:"description"`
+	Permissions []Permission `json:"permissions" db:"-"`
+	CreatedAt   time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt   *time.Time   `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Session struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	ClientId   string    `json:"clientId,omitempty"`
+	UserAgent  string    `json:"userAgent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+}
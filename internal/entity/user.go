@@ -3,17 +3,34 @@ package entity
 import "time"
 
 type User struct {
-	Id             string     `json:"id" db:"id"`
-	LastName       *string    `json:"lastName" db:"last_name"`
-	FirstName      *string    `json:"firstName" db:"first_name"`
-	MiddleName     *string    `json:"middleName" db:"middle_name"`
-	Email          string     `json:"email" db:"email"`
-	HashedPassword string     `json:"password" db:"hashed_password"`
-	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt      *time.Time `json:"updatedAt" db:"updated_at"`
+	Id              string     `json:"id" db:"id"`
+	LastName        *string    `json:"lastName" db:"last_name"`
+	FirstName       *string    `json:"firstName" db:"first_name"`
+	MiddleName      *string    `json:"middleName" db:"middle_name"`
+	Email           string     `json:"email" db:"email"`
+	HashedPassword  string     `json:"password" db:"hashed_password"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt" db:"email_verified_at"`
+	DisabledAt      *time.Time `json:"disabledAt" db:"disabled_at"`
+	DeletedAt       *time.Time `json:"deletedAt" db:"deleted_at"`
+	TotpSecret      []byte     `json:"-" db:"totp_secret"`
+	TotpEnabledAt   *time.Time `json:"totpEnabledAt" db:"totp_enabled_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updatedAt" db:"updated_at"`
 }
 
 type UserClaims struct {
-	Id    string
-	Email string
+	Id             string
+	Email          string
+	SessionId      string
+	OrganizationId string
+	TokenId        string
+	ClientId       string
+	Scope          []string
+	Audience       []string
+	Roles          []Role
+	Permissions    []Permission
+	Mfa            bool
+	IssuedAt       time.Time
+	NotBefore      time.Time
+	ExpiresAt      time.Time
 }
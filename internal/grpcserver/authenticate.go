@@ -0,0 +1,31 @@
+package grpcserver
+
+import (
+	"context"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/pkg/authpb"
+)
+
+func (s *Server) Authenticate(ctx context.Context, req *authpb.AuthenticateRequest) (*authpb.AuthenticateResponse, error) {
+	u, claims, err := s.as.Authenticate(ctx, &dto.Authenticate{
+		AccessToken: req.AccessToken,
+		Roles:       toRoles(req.Roles),
+		Global:      !req.OrganizationScope,
+	})
+	if err != nil {
+		s.logger.Debug("authenticate failed", sl.Err(err))
+		return nil, statusError(err)
+	}
+
+	res := &authpb.AuthenticateResponse{
+		User:      user(u),
+		SessionId: claims.SessionId,
+	}
+	if req.OrganizationScope {
+		res.OrganizationId = claims.OrganizationId
+	}
+
+	return res, nil
+}
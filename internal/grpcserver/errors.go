@@ -0,0 +1,28 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/services/authservice"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrTokenInvalid),
+		errors.Is(err, authservice.ErrTokenExpired),
+		errors.Is(err, authservice.ErrTokenRevoked):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, authservice.ErrInsufficientPermission),
+		errors.Is(err, authservice.ErrUserDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, authservice.ErrMfaEnrollmentRequired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, authservice.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,42 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequireClient only lets through calls that carry a client credentials
+// access token, so the API is reachable by registered services only.
+func RequireClient(as *authservice.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		bearer := md.Get("authorization")
+		if len(bearer) == 0 || !strings.HasPrefix(bearer[0], "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "client token not found")
+		}
+
+		claims, err := as.Introspect(ctx, strings.TrimPrefix(bearer[0], "Bearer "))
+		if err != nil {
+			slog.Warn("client token rejected", slog.String("method", info.FullMethod), sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "client token invalid")
+		}
+
+		if claims.ClientId == "" {
+			return nil, status.Error(codes.PermissionDenied, "client token required")
+		}
+
+		slog.Debug("grpc call", slog.String("method", info.FullMethod), slog.String("clientId", claims.ClientId))
+
+		return handler(ctx, req)
+	}
+}
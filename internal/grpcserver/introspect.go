@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/pkg/authpb"
+)
+
+func (s *Server) Introspect(ctx context.Context, req *authpb.IntrospectRequest) (*authpb.IntrospectResponse, error) {
+	claims, err := s.as.Introspect(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrTokenRevoked) {
+			s.logger.Debug("inactive token", sl.Err(err))
+			return &authpb.IntrospectResponse{Active: false}, nil
+		}
+		return nil, statusError(err)
+	}
+
+	return &authpb.IntrospectResponse{
+		Active:   true,
+		Sub:      claims.Id,
+		ClientId: claims.ClientId,
+		Email:    claims.Email,
+		Scope:    strings.Join(claims.Scope, " "),
+		Aud:      claims.Audience,
+		Jti:      claims.TokenId,
+		Sid:      claims.SessionId,
+		Iat:      claims.IssuedAt.Unix(),
+		Exp:      claims.ExpiresAt.Unix(),
+	}, nil
+}
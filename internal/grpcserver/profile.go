@@ -0,0 +1,21 @@
+package grpcserver
+
+import (
+	"context"
+
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/pkg/authpb"
+)
+
+func (s *Server) Profile(ctx context.Context, req *authpb.ProfileRequest) (*authpb.ProfileResponse, error) {
+	u, r, err := s.as.Profile(ctx, req.UserId)
+	if err != nil {
+		s.logger.Debug("profile failed", sl.Err(err))
+		return nil, statusError(err)
+	}
+
+	return &authpb.ProfileResponse{
+		User:  user(u),
+		Roles: roles(r),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package grpcserver
+
+import (
+	"context"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/pkg/authpb"
+)
+
+func (s *Server) CheckRoles(ctx context.Context, req *authpb.CheckRolesRequest) (*authpb.CheckRolesResponse, error) {
+	ok, err := s.as.CheckRoles(ctx, &dto.CheckRoles{
+		UserId: req.UserId,
+		Roles:  toRoles(req.Roles),
+	})
+	if err != nil {
+		s.logger.Debug("check roles failed", sl.Err(err))
+		return nil, statusError(err)
+	}
+
+	return &authpb.CheckRolesResponse{Ok: ok}, nil
+}
@@ -0,0 +1,57 @@
+package grpcserver
+
+import (
+	"log/slog"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/pkg/authpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Server struct {
+	authpb.UnimplementedAuthServiceServer
+
+	as     *authservice.AuthService
+	logger *slog.Logger
+}
+
+func New(as *authservice.AuthService) *grpc.Server {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(RequireClient(as)))
+
+	authpb.RegisterAuthServiceServer(srv, &Server{
+		as:     as,
+		logger: slog.Default().With(slog.String("struct", "grpcserver.Server")),
+	})
+
+	return srv
+}
+
+func user(u *entity.User) *authpb.User {
+	return &authpb.User{
+		Id:         u.Id,
+		Email:      u.Email,
+		FirstName:  u.FirstName,
+		LastName:   u.LastName,
+		MiddleName: u.MiddleName,
+		CreatedAt:  timestamppb.New(u.CreatedAt),
+	}
+}
+
+func roles(r []entity.Role) []string {
+	res := make([]string, 0, len(r))
+	for _, role := range r {
+		res = append(res, role.String())
+	}
+	return res
+}
+
+func toRoles(r []string) []entity.Role {
+	res := make([]entity.Role, 0, len(r))
+	for _, role := range r {
+		res = append(res, entity.Role(role))
+	}
+	return res
+}
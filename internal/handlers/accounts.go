@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+type accountResponse struct {
+	Id         string     `json:"id"`
+	Email      string     `json:"email"`
+	DisabledAt *time.Time `json:"disabledAt"`
+}
+
+func DisableUser(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := c.Get(mw.USER).(*entity.User)
+
+		user, err := as.DisableUser(c.Request().Context(), &dto.DisableUser{
+			UserId:  c.Param("id"),
+			ActorId: actor.Id,
+		})
+		if err != nil {
+			return accountError(c, err)
+		}
+
+		return c.JSON(200, &accountResponse{
+			Id:         user.Id,
+			Email:      user.Email,
+			DisabledAt: user.DisabledAt,
+		})
+	}
+}
+
+func EnableUser(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := as.EnableUser(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return accountError(c, err)
+		}
+
+		return c.JSON(200, &accountResponse{
+			Id:         user.Id,
+			Email:      user.Email,
+			DisabledAt: user.DisabledAt,
+		})
+	}
+}
+
+func DeleteAccount(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Password string `json:"password"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.DeleteAccount(c.Request().Context(), &dto.DeleteAccount{
+			UserId:   user.Id,
+			Password: req.Password,
+		}); err != nil {
+			return accountError(c, err)
+		}
+
+		return c.NoContent(204)
+	}
+}
+
+func accountError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrUserNotFound):
+		return responses.NotFound(c)
+	case errors.Is(err, authservice.ErrInvalidRequest), errors.Is(err, authservice.ErrInvalidCredentials):
+		return responses.BadRequest(c, err)
+	}
+	return responses.Internal(c, err)
+}
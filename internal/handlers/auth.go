@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"strings"
+
+	"mzhn/auth/internal/config"
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/responses"
@@ -11,39 +15,146 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func Auth(as *authservice.AuthService) echo.HandlerFunc {
-	type request struct {
-		Roles []entity.Role `json:"roles"`
-	}
+const (
+	HeaderUserId         = "X-User-Id"
+	HeaderUserEmail      = "X-User-Email"
+	HeaderUserRoles      = "X-User-Roles"
+	HeaderOrganizationId = "X-Organization-Id"
+)
 
+// Auth is a forward-auth endpoint for nginx auth_request and Traefik ForwardAuth.
+// Required roles come comma separated from the roles query parameter, required permissions
+// likewise from the permissions query parameter. Both are set in the proxy configuration,
+// request headers are never consulted since proxies forward them from the client.
+// Roles are checked outside of any organization, scope=organization also accepts the roles
+// held in the organization of the token and reports it in X-Organization-Id.
+// With JWT_STATELESS set the roles are taken from the access token claims.
+func Auth(as *authservice.AuthService, cfg *config.Config) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		var req request
-
 		token := c.Get(middleware.TOKEN)
-		if token != nil {
-			return c.JSON(echo.ErrUnauthorized.Code, map[string]any{
-				"error": "unathorized",
-			})
-		}
-
-		if err := c.Bind(&req); err != nil {
-			return err
+		if token == nil {
+			return responses.Unauthorized(c)
 		}
 
 		ctx := c.Request().Context()
+		scoped := c.QueryParam("scope") == "organization"
 
-		_, err := as.Authenticate(ctx, &dto.Authenticate{
+		userId, email, claims, err := authenticate(ctx, as, cfg, &dto.Authenticate{
 			AccessToken: token.(string),
-			Roles:       req.Roles,
+			Roles:       requiredRoles(c),
+			Permissions: requiredPermissions(c),
+			Global:      !scoped,
 		})
 		if err != nil {
 			if errors.Is(err, authservice.ErrInsufficientPermission) {
 				return responses.Forbidden(c)
+			} else if errors.Is(err, authservice.ErrMfaEnrollmentRequired) {
+				return c.JSON(403, responses.Payload{"error": err.Error()})
+			} else if errors.Is(err, authservice.ErrTokenInvalid) ||
+				errors.Is(err, authservice.ErrTokenExpired) ||
+				errors.Is(err, authservice.ErrTokenRevoked) ||
+				errors.Is(err, authservice.ErrUserNotFound) ||
+				errors.Is(err, authservice.ErrUserDisabled) {
+				return responses.Unauthorized(c)
 			}
 
 			return responses.Internal(c, err)
 		}
 
-		return responses.Ok(c, responses.Payload{})
+		organizationId := ""
+		if scoped {
+			organizationId = claims.OrganizationId
+		}
+
+		roles, err := effectiveRoles(ctx, as, cfg, claims, organizationId)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			names = append(names, role.String())
+		}
+
+		header := c.Response().Header()
+		header.Set(HeaderUserId, userId)
+		header.Set(HeaderUserEmail, email)
+		header.Set(HeaderUserRoles, strings.Join(names, ","))
+		if organizationId != "" {
+			header.Set(HeaderOrganizationId, organizationId)
+		}
+
+		return c.NoContent(200)
+	}
+}
+
+// authenticate checks the request against the stored user, or against the token claims alone
+// when JWT_STATELESS is set. The email then is the one the token was issued with.
+func authenticate(ctx context.Context, as *authservice.AuthService, cfg *config.Config, req *dto.Authenticate) (string, string, *entity.UserClaims, error) {
+	if cfg.Jwt.Stateless {
+		claims, err := as.AuthenticateClaims(ctx, req)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return claims.Id, claims.Email, claims, nil
+	}
+
+	user, claims, err := as.Authenticate(ctx, req)
+	if err != nil {
+		return "", "", nil, err
 	}
+	return user.Id, user.Email, claims, nil
+}
+
+// effectiveRoles lists the roles of the token owner in the scope that was checked.
+// Claims of an organization token mix global and organization roles, so in the global
+// scope they are reported only for tokens outside of any organization.
+func effectiveRoles(ctx context.Context, as *authservice.AuthService, cfg *config.Config, claims *entity.UserClaims, organizationId string) ([]entity.Role, error) {
+	if !cfg.Jwt.Stateless {
+		return as.EffectiveRoles(ctx, claims.Id, organizationId)
+	}
+
+	if claims.OrganizationId != organizationId {
+		return nil, nil
+	}
+
+	return claims.Roles, nil
+}
+
+func requiredRoles(c echo.Context) []entity.Role {
+	names := requiredList(c, "roles")
+
+	roles := make([]entity.Role, 0, len(names))
+	for _, name := range names {
+		roles = append(roles, entity.Role(name))
+	}
+
+	return roles
+}
+
+func requiredPermissions(c echo.Context) []entity.Permission {
+	names := requiredList(c, "permissions")
+
+	permissions := make([]entity.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, entity.Permission(name))
+	}
+
+	return permissions
+}
+
+// requiredList collects comma separated values of the query parameter.
+func requiredList(c echo.Context, param string) []string {
+	values := c.QueryParams()[param]
+
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
 }
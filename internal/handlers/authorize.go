@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<form method="post" action="/authorize">
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+<input type="hidden" name="{{.CsrfField}}" value="{{.Csrf}}">
+<input type="hidden" name="response_type" value="{{.Params.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Params.ClientId}}">
+<input type="hidden" name="redirect_uri" value="{{.Params.RedirectUri}}">
+<input type="hidden" name="scope" value="{{.Params.Scope}}">
+<input type="hidden" name="state" value="{{.Params.State}}">
+<input type="hidden" name="nonce" value="{{.Params.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Params.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Params.CodeChallengeMethod}}">
+<label>Email <input type="email" name="email" required autofocus></label>
+<label>Password <input type="password" name="password" required></label>
+<label>Authentication or recovery code <input type="text" name="otp" autocomplete="one-time-code"></label>
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+func authorizeParams(c echo.Context) dto.AuthorizeParams {
+	return dto.AuthorizeParams{
+		ResponseType:        c.FormValue("response_type"),
+		ClientId:            c.FormValue("client_id"),
+		RedirectUri:         c.FormValue("redirect_uri"),
+		Scope:               c.FormValue("scope"),
+		State:               c.FormValue("state"),
+		Nonce:               c.FormValue("nonce"),
+		CodeChallenge:       c.FormValue("code_challenge"),
+		CodeChallengeMethod: c.FormValue("code_challenge_method"),
+	}
+}
+
+func renderAuthorize(c echo.Context, status int, params *dto.AuthorizeParams, message string) error {
+	c.Response().Header().Set("X-Frame-Options", "DENY")
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(status)
+
+	csrf, _ := c.Get(mw.CSRF).(string)
+
+	return authorizeTemplate.Execute(c.Response(), map[string]any{
+		"Params":    params,
+		"Error":     message,
+		"CsrfField": mw.CSRFField,
+		"Csrf":      csrf,
+	})
+}
+
+// authorizeError reports errors back to the client through its redirect uri,
+// unless the client or the redirect uri itself cannot be trusted.
+func authorizeError(c echo.Context, params *dto.AuthorizeParams, err error) error {
+	if errors.Is(err, authservice.ErrInvalidClient) || errors.Is(err, authservice.ErrInvalidRedirectUri) {
+		return responses.BadRequest(c, err)
+	}
+
+	code, ok := oauthErrorCode(err)
+	if !ok {
+		return responses.Internal(c, err)
+	}
+
+	return redirectWith(c, params.RedirectUri, url.Values{
+		"error":             {code},
+		"error_description": {err.Error()},
+		"state":             {params.State},
+	})
+}
+
+func AuthorizeForm(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := authorizeParams(c)
+
+		if err := as.CheckAuthorize(c.Request().Context(), &params); err != nil {
+			return authorizeError(c, &params, err)
+		}
+
+		return renderAuthorize(c, http.StatusOK, &params, "")
+	}
+}
+
+func Authorize(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := authorizeParams(c)
+
+		code, err := as.Authorize(c.Request().Context(), &dto.Authorize{
+			AuthorizeParams: params,
+			Email:           c.FormValue("email"),
+			Password:        c.FormValue("password"),
+			Otp:             c.FormValue("otp"),
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrInvalidCredentials) {
+				return renderAuthorize(c, http.StatusUnauthorized, &params, "Invalid email or password")
+			}
+			if errors.Is(err, authservice.ErrEmailNotVerified) {
+				return renderAuthorize(c, http.StatusForbidden, &params, "Confirm your email address before signing in")
+			}
+			if errors.Is(err, authservice.ErrMfaRequired) {
+				return renderAuthorize(c, http.StatusUnauthorized, &params, "Enter the code from your authenticator app")
+			}
+			if errors.Is(err, authservice.ErrMfaCodeInvalid) {
+				return renderAuthorize(c, http.StatusUnauthorized, &params, "Invalid authentication code")
+			}
+			if errors.Is(err, authservice.ErrUserDisabled) {
+				return renderAuthorize(c, http.StatusForbidden, &params, "Your account is disabled")
+			}
+			return authorizeError(c, &params, err)
+		}
+
+		return redirectWith(c, params.RedirectUri, url.Values{
+			"code":  {code},
+			"state": {params.State},
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func CreateClient(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Name         string   `json:"name"`
+		RedirectUris []string `json:"redirectUris"`
+		Scopes       []string `json:"scopes"`
+		GrantTypes   []string `json:"grantTypes"`
+		Confidential bool     `json:"confidential"`
+	}
+
+	type response struct {
+		*entity.Client
+		Secret string `json:"secret,omitempty"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		client, secret, err := as.CreateClient(c.Request().Context(), &dto.CreateClient{
+			Name:         req.Name,
+			RedirectUris: req.RedirectUris,
+			Scopes:       req.Scopes,
+			GrantTypes:   req.GrantTypes,
+			Confidential: req.Confidential,
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrInvalidRequest) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{
+			Client: client,
+			Secret: secret,
+		})
+	}
+}
+
+func Clients(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Clients []entity.Client `json:"clients"`
+	}
+
+	return func(c echo.Context) error {
+		clients, err := as.Clients(c.Request().Context())
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{Clients: clients})
+	}
+}
+
+func DeleteClient(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := as.DeleteClient(c.Request().Context(), c.Param("id")); err != nil {
+			if errors.Is(err, authservice.ErrClientNotFound) {
+				return responses.NotFound(c)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
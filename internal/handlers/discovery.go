@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Discovery(as *authservice.AuthService) echo.HandlerFunc {
+
+	type response struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+		IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+		RevocationEndpoint                string   `json:"revocation_endpoint"`
+		JwksUri                           string   `json:"jwks_uri"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported"`
+		SubjectTypesSupported             []string `json:"subject_types_supported"`
+		IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+		ScopesSupported                   []string `json:"scopes_supported"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		ClaimsSupported                   []string `json:"claims_supported"`
+	}
+
+	return func(c echo.Context) error {
+		d := as.Discovery()
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(200, &response{
+			Issuer:                            d.Issuer,
+			AuthorizationEndpoint:             d.Issuer + "/authorize",
+			TokenEndpoint:                     d.Issuer + "/token",
+			UserinfoEndpoint:                  d.Issuer + "/userinfo",
+			IntrospectionEndpoint:             d.Issuer + "/introspect",
+			RevocationEndpoint:                d.Issuer + "/revoke",
+			JwksUri:                           d.Issuer + "/.well-known/jwks.json",
+			ResponseTypesSupported:            []string{"code"},
+			GrantTypesSupported:               []string{authservice.GrantAuthorizationCode, authservice.GrantRefreshToken, authservice.GrantClientCredentials},
+			SubjectTypesSupported:             []string{"public"},
+			IdTokenSigningAlgValuesSupported:  d.SigningAlgs,
+			ScopesSupported:                   []string{authservice.ScopeOpenId, authservice.ScopeProfile, authservice.ScopeEmail},
+			TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+			CodeChallengeMethodsSupported:     []string{"S256"},
+			ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "given_name", "family_name", "middle_name"},
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strings"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Introspect(as *authservice.AuthService) echo.HandlerFunc {
+
+	type response struct {
+		Active    bool     `json:"active"`
+		Scope     string   `json:"scope,omitempty"`
+		ClientId  string   `json:"client_id,omitempty"`
+		Username  string   `json:"username,omitempty"`
+		TokenType string   `json:"token_type,omitempty"`
+		Exp       int64    `json:"exp,omitempty"`
+		Iat       int64    `json:"iat,omitempty"`
+		Nbf       int64    `json:"nbf,omitempty"`
+		Sub       string   `json:"sub,omitempty"`
+		Aud       []string `json:"aud,omitempty"`
+		Iss       string   `json:"iss,omitempty"`
+		Jti       string   `json:"jti,omitempty"`
+		Sid       string   `json:"sid,omitempty"`
+		OrgId     string   `json:"org_id,omitempty"`
+	}
+
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+		clientId, clientSecret, err := clientCredentials(c)
+		if err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		token := c.FormValue("token")
+		if token == "" {
+			return oauthError(c, authservice.ErrInvalidRequest)
+		}
+
+		result, err := as.IntrospectToken(c.Request().Context(), &dto.IntrospectToken{
+			Token:         token,
+			TokenTypeHint: c.FormValue("token_type_hint"),
+			ClientId:      clientId,
+			ClientSecret:  clientSecret,
+		})
+		if err != nil {
+			return oauthError(c, err)
+		}
+
+		if !result.Active {
+			return c.JSON(200, &response{Active: false})
+		}
+
+		claims := result.Claims
+		tokenType := "refresh_token"
+		if result.TokenType == authservice.TokenTypeAccess {
+			tokenType = "Bearer"
+		}
+
+		res := &response{
+			Active:    true,
+			Scope:     strings.Join(claims.Scope, " "),
+			ClientId:  claims.ClientId,
+			Username:  claims.Email,
+			TokenType: tokenType,
+			Exp:       claims.ExpiresAt.Unix(),
+			Iat:       claims.IssuedAt.Unix(),
+			Sub:       claims.Id,
+			Aud:       claims.Audience,
+			Iss:       result.Issuer,
+			Jti:       claims.TokenId,
+			Sid:       claims.SessionId,
+			OrgId:     claims.OrganizationId,
+		}
+		if !claims.NotBefore.IsZero() {
+			res.Nbf = claims.NotBefore.Unix()
+		}
+
+		return c.JSON(200, res)
+	}
+}
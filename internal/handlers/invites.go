@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func CreateInvite(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Email *string       `json:"email"`
+		Roles []entity.Role `json:"roles"`
+	}
+
+	type response struct {
+		*entity.Invite
+		Token string `json:"token"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		actor := c.Get(mw.USER).(*entity.User)
+
+		token, invite, err := as.CreateInvite(c.Request().Context(), &dto.CreateInvite{
+			Email:     req.Email,
+			Roles:     req.Roles,
+			CreatedBy: actor.Id,
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrRoleNotFound) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{
+			Invite: invite,
+			Token:  token,
+		})
+	}
+}
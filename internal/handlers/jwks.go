@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func JWKS(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(200, as.JWKS())
+	}
+}
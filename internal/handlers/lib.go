@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func device(c echo.Context) dto.Device {
+	return dto.Device{
+		UserAgent: c.Request().UserAgent(),
+		Ip:        c.RealIP(),
+	}
+}
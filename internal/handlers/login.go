@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
 	"mzhn/auth/internal/services/authservice"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -11,6 +14,7 @@ func Login(as *authservice.AuthService) echo.HandlerFunc {
 	type request struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Audience string `json:"audience"`
 	}
 
 	type response struct {
@@ -18,6 +22,12 @@ func Login(as *authservice.AuthService) echo.HandlerFunc {
 		RefreshToken string `json:"refreshToken"`
 	}
 
+	type challengeResponse struct {
+		MfaRequired bool      `json:"mfaRequired"`
+		MfaToken    string    `json:"mfaToken"`
+		ExpiresAt   time.Time `json:"expiresAt"`
+	}
+
 	return func(c echo.Context) error {
 		var req request
 
@@ -25,16 +35,35 @@ func Login(as *authservice.AuthService) echo.HandlerFunc {
 			return err
 		}
 
-		tokens, err := as.Login(c.Request().Context(), &dto.Login{
+		tokens, challenge, err := as.Login(c.Request().Context(), &dto.Login{
 			Email:    req.Email,
 			Password: req.Password,
+			Audience: req.Audience,
+			Device:   device(c),
 		})
 		if err != nil {
+			if errors.Is(err, authservice.ErrAudienceInvalid) {
+				return responses.BadRequest(c, err)
+			}
+			if errors.Is(err, authservice.ErrInvalidCredentials) {
+				return responses.Unauthorized(c)
+			}
+			if errors.Is(err, authservice.ErrEmailNotVerified) || errors.Is(err, authservice.ErrUserDisabled) {
+				return c.JSON(403, responses.Payload{"error": err.Error()})
+			}
 			return c.JSON(echo.ErrInternalServerError.Code, map[string]any{
 				"message": err.Error(),
 			})
 		}
 
+		if challenge != nil {
+			return c.JSON(200, &challengeResponse{
+				MfaRequired: true,
+				MfaToken:    challenge.Token,
+				ExpiresAt:   challenge.ExpiresAt,
+			})
+		}
+
 		return c.JSON(200, &response{
 			AccessToken:  tokens.AccessToken,
 			RefreshToken: tokens.RefreshToken,
@@ -11,10 +11,10 @@ import (
 func Logout(as *authservice.AuthService) echo.HandlerFunc {
 	return func(c echo.Context) error {
 
-		user := c.Get(mw.USER).(*entity.User)
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
 		ctx := c.Request().Context()
 
-		if err := as.Logout(ctx, user.Id); err != nil {
+		if err := as.Logout(ctx, claims); err != nil {
 			return c.JSON(echo.ErrInternalServerError.Code, map[string]any{
 				"message": err.Error(),
 			})
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func VerifyMfa(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		MfaToken string `json:"mfaToken"`
+		Code     string `json:"code"`
+		Audience string `json:"audience"`
+	}
+
+	type response struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		tokens, err := as.VerifyMfa(c.Request().Context(), &dto.VerifyMfa{
+			Token:    req.MfaToken,
+			Code:     req.Code,
+			Audience: req.Audience,
+			Device:   device(c),
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrMfaCodeInvalid) ||
+				errors.Is(err, authservice.ErrUserNotFound) || errors.Is(err, authservice.ErrUserDisabled) {
+				return responses.Unauthorized(c)
+			}
+			if errors.Is(err, authservice.ErrAudienceInvalid) {
+				return responses.BadRequest(c, err)
+			}
+			return mfaError(c, err)
+		}
+
+		return c.JSON(200, &response{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
+	}
+}
+
+func EnrollTotp(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Secret string `json:"secret"`
+		Uri    string `json:"uri"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		enrollment, err := as.EnrollTotp(c.Request().Context(), user.Id)
+		if err != nil {
+			return mfaError(c, err)
+		}
+
+		return c.JSON(200, &response{
+			Secret: enrollment.Secret,
+			Uri:    enrollment.Uri,
+		})
+	}
+}
+
+func ConfirmTotp(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		codes, err := as.ConfirmTotp(c.Request().Context(), user.Id, req.Code)
+		if err != nil {
+			return mfaError(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"enabled": true, "recoveryCodes": codes})
+	}
+}
+
+func DisableTotp(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.DisableTotp(c.Request().Context(), user.Id, req.Code); err != nil {
+			return mfaError(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"enabled": false})
+	}
+}
+
+func RegenerateRecoveryCodes(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		codes, err := as.RegenerateRecoveryCodes(c.Request().Context(), user.Id, req.Code)
+		if err != nil {
+			return mfaError(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"recoveryCodes": codes})
+	}
+}
+
+func RecoveryCodesLeft(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		left, err := as.RecoveryCodesLeft(c.Request().Context(), user.Id)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"left": left})
+	}
+}
+
+func mfaError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrMfaCodeInvalid),
+		errors.Is(err, authservice.ErrMfaNotEnrolled),
+		errors.Is(err, authservice.ErrMfaAlreadyEnabled):
+		return responses.BadRequest(c, err)
+	case errors.Is(err, authservice.ErrMfaUnavailable):
+		return c.JSON(http.StatusNotImplemented, responses.Payload{"error": err.Error()})
+	}
+	return responses.Internal(c, err)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+// oauthErrors maps service errors to RFC 6749 error codes.
+var oauthErrors = []struct {
+	err  error
+	code string
+}{
+	{authservice.ErrInvalidRequest, "invalid_request"},
+	{authservice.ErrInvalidClient, "invalid_client"},
+	{authservice.ErrInvalidRedirectUri, "invalid_request"},
+	{authservice.ErrInvalidScope, "invalid_scope"},
+	{authservice.ErrInvalidGrant, "invalid_grant"},
+	{authservice.ErrUnauthorizedClient, "unauthorized_client"},
+	{authservice.ErrUnsupportedResponseType, "unsupported_response_type"},
+	{authservice.ErrUnsupportedGrantType, "unsupported_grant_type"},
+}
+
+func oauthErrorCode(err error) (string, bool) {
+	for _, e := range oauthErrors {
+		if errors.Is(err, e.err) {
+			return e.code, true
+		}
+	}
+	return "", false
+}
+
+// oauthError writes an RFC 6749 section 5.2 error response.
+func oauthError(c echo.Context, err error) error {
+	code, ok := oauthErrorCode(err)
+	if !ok {
+		return responses.Internal(c, err)
+	}
+
+	status := http.StatusBadRequest
+	if code == "invalid_client" {
+		status = http.StatusUnauthorized
+	}
+
+	return c.JSON(status, responses.Payload{
+		"error":             code,
+		"error_description": err.Error(),
+	})
+}
+
+// clientCredentials reads client_secret_basic or client_secret_post credentials.
+func clientCredentials(c echo.Context) (string, string, error) {
+	id, secret, ok := c.Request().BasicAuth()
+	if !ok {
+		return c.FormValue("client_id"), c.FormValue("client_secret"), nil
+	}
+
+	// RFC 6749 section 2.3.1 form-encodes credentials before basic auth
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return id, secret, nil
+}
+
+func redirectWith(c echo.Context, redirectUri string, params url.Values) error {
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return responses.BadRequest(c, err)
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		for _, value := range v {
+			if value != "" {
+				q.Add(k, value)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return c.Redirect(http.StatusFound, u.String())
+}
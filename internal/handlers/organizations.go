@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func CreateOrganization(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		user := c.Get(mw.USER).(*entity.User)
+
+		organization, err := as.CreateOrganization(c.Request().Context(), &dto.CreateOrganization{
+			Name:    req.Name,
+			Slug:    req.Slug,
+			OwnerId: user.Id,
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrInvalidRequest) || errors.Is(err, authservice.ErrOrganizationExists) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, organization)
+	}
+}
+
+func Organizations(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Organizations []entity.Organization `json:"organizations"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		organizations, err := as.Organizations(c.Request().Context(), user.Id)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{Organizations: organizations})
+	}
+}
+
+func Members(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Members []entity.Member `json:"members"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		members, err := as.Members(c.Request().Context(), c.Param("org"), user.Id)
+		if err != nil {
+			if errors.Is(err, authservice.ErrNotMember) {
+				return responses.Forbidden(c)
+			}
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, &response{Members: members})
+	}
+}
+
+func AddMember(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		UserId string        `json:"userId"`
+		Roles  []entity.Role `json:"roles"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		actor := c.Get(mw.USER).(*entity.User)
+
+		if err := as.AddMember(c.Request().Context(), &dto.AddMember{
+			OrganizationId: c.Param("org"),
+			UserId:         req.UserId,
+			ActorId:        actor.Id,
+			Roles:          req.Roles,
+		}); err != nil {
+			if errors.Is(err, authservice.ErrUserNotFound) {
+				return responses.BadRequest(c, err)
+			}
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
+
+func RemoveMember(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := c.Get(mw.USER).(*entity.User)
+
+		if err := as.RemoveMember(c.Request().Context(), &dto.RemoveMember{
+			OrganizationId: c.Param("org"),
+			UserId:         c.Param("id"),
+			ActorId:        actor.Id,
+		}); err != nil {
+			if errors.Is(err, authservice.ErrNotMember) {
+				return responses.NotFound(c)
+			}
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
+
+// SwitchOrganization takes the refresh token like Refresh does and returns tokens scoped to the organization.
+func SwitchOrganization(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		OrganizationId string `json:"organizationId"`
+	}
+
+	type response struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		token := c.Get(mw.TOKEN)
+		if token == nil {
+			return responses.Unauthorized(c)
+		}
+
+		tokens, err := as.SwitchOrganization(c.Request().Context(), &dto.SwitchOrganization{
+			RefreshToken:   token.(string),
+			OrganizationId: req.OrganizationId,
+			Device:         device(c),
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, authservice.ErrTokenInvalid), errors.Is(err, authservice.ErrTokenReused):
+				return responses.Unauthorized(c)
+			case errors.Is(err, authservice.ErrNotMember):
+				return responses.Forbidden(c)
+			case errors.Is(err, authservice.ErrOrganizationNotFound):
+				return responses.NotFound(c)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/lib/webauthn"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+type passkeyResponse struct {
+	Id         string     `json:"id"`
+	Name       *string    `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func newPasskeyResponse(passkey *entity.Passkey) *passkeyResponse {
+	return &passkeyResponse{
+		Id:         base64.RawURLEncoding.EncodeToString(passkey.Id),
+		Name:       passkey.Name,
+		CreatedAt:  passkey.CreatedAt,
+		LastUsedAt: passkey.LastUsedAt,
+	}
+}
+
+func BeginPasskeyRegistration(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		options, err := as.BeginPasskeyRegistration(c.Request().Context(), &dto.BeginPasskeyRegistration{
+			UserId:   user.Id,
+			Password: req.Password,
+			Code:     req.Code,
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrInvalidCredentials) || errors.Is(err, authservice.ErrMfaRequired) {
+				return responses.BadRequest(c, err)
+			}
+			return mfaError(c, err)
+		}
+
+		return c.JSON(200, echo.Map{"publicKey": options})
+	}
+}
+
+func FinishPasskeyRegistration(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Name       *string                          `json:"name"`
+		Credential *webauthn.RegistrationCredential `json:"credential"`
+	}
+
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if req.Credential == nil {
+			return responses.BadRequest(c, authservice.ErrInvalidRequest)
+		}
+
+		passkey, err := as.FinishPasskeyRegistration(c.Request().Context(), &dto.RegisterPasskey{
+			UserId:     user.Id,
+			Name:       req.Name,
+			Credential: req.Credential,
+		})
+		if err != nil {
+			return passkeyError(c, err)
+		}
+
+		return c.JSON(201, newPasskeyResponse(passkey))
+	}
+}
+
+func Passkeys(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		passkeys, err := as.Passkeys(c.Request().Context(), user.Id)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		result := make([]*passkeyResponse, 0, len(passkeys))
+		for i := range passkeys {
+			result = append(result, newPasskeyResponse(&passkeys[i]))
+		}
+
+		return c.JSON(200, result)
+	}
+}
+
+func DeletePasskey(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := c.Get(mw.USER).(*entity.User)
+
+		id, err := base64.RawURLEncoding.DecodeString(c.Param("id"))
+		if err != nil {
+			return responses.NotFound(c)
+		}
+
+		if err := as.DeletePasskey(c.Request().Context(), user.Id, id); err != nil {
+			return passkeyError(c, err)
+		}
+
+		return c.NoContent(204)
+	}
+}
+
+func BeginPasskeyLogin(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		options, err := as.BeginPasskeyLogin(c.Request().Context())
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, echo.Map{"publicKey": options})
+	}
+}
+
+func PasskeyLogin(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Audience   string                        `json:"audience"`
+		Credential *webauthn.AssertionCredential `json:"credential"`
+	}
+
+	type response struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if req.Credential == nil {
+			return responses.BadRequest(c, authservice.ErrInvalidRequest)
+		}
+
+		tokens, err := as.PasskeyLogin(c.Request().Context(), &dto.PasskeyLogin{
+			Credential: req.Credential,
+			Audience:   req.Audience,
+			Device:     device(c),
+		})
+		if err != nil {
+			if errors.Is(err, authservice.ErrPasskeyInvalid) || errors.Is(err, authservice.ErrUserNotFound) {
+				return responses.Unauthorized(c)
+			}
+			if errors.Is(err, authservice.ErrEmailNotVerified) || errors.Is(err, authservice.ErrUserDisabled) {
+				return c.JSON(403, responses.Payload{"error": err.Error()})
+			}
+			if errors.Is(err, authservice.ErrAudienceInvalid) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
+	}
+}
+
+func passkeyError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrPasskeyNotFound):
+		return responses.NotFound(c)
+	case errors.Is(err, authservice.ErrPasskeyInvalid), errors.Is(err, authservice.ErrPasskeyExists):
+		return responses.BadRequest(c, err)
+	}
+	return responses.Internal(c, err)
+}
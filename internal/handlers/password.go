@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ForgotPassword(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if req.Email == "" {
+			return responses.BadRequest(c, authservice.ErrInvalidRequest)
+		}
+
+		if err := as.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"sent": true})
+	}
+}
+
+func ResetPassword(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.ResetPassword(c.Request().Context(), &dto.ResetPassword{
+			Token:    req.Token,
+			Password: req.Password,
+		}); err != nil {
+			if errors.Is(err, authservice.ErrInvalidRequest) {
+				return responses.BadRequest(c, err)
+			}
+			if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrUserNotFound) ||
+				errors.Is(err, authservice.ErrUserDisabled) {
+				return responses.BadRequest(c, authservice.ErrTokenInvalid)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"reset": true})
+	}
+}
+
+func ChangePassword(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+
+	return func(c echo.Context) error {
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.ChangePassword(c.Request().Context(), &dto.ChangePassword{
+			UserId:          claims.Id,
+			SessionId:       claims.SessionId,
+			CurrentPassword: req.CurrentPassword,
+			NewPassword:     req.NewPassword,
+		}); err != nil {
+			if errors.Is(err, authservice.ErrInvalidRequest) || errors.Is(err, authservice.ErrInvalidCredentials) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"changed": true})
+	}
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"log/slog"
+	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/lib/responses"
 	mw "mzhn/auth/internal/middleware"
 	"mzhn/auth/internal/services/authservice"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -13,12 +17,13 @@ import (
 func Profile(as *authservice.AuthService) echo.HandlerFunc {
 
 	type response struct {
-		Id         string        `json:"id"`
-		LastName   *string       `json:"lastName"`
-		FirstName  *string       `json:"firstName"`
-		MiddleName *string       `json:"middleName"`
-		Email      string        `json:"email"`
-		Roles      []entity.Role `json:"roles"`
+		Id              string        `json:"id"`
+		LastName        *string       `json:"lastName"`
+		FirstName       *string       `json:"firstName"`
+		MiddleName      *string       `json:"middleName"`
+		Email           string        `json:"email"`
+		EmailVerifiedAt *time.Time    `json:"emailVerifiedAt"`
+		Roles           []entity.Role `json:"roles"`
 	}
 
 	return func(c echo.Context) error {
@@ -33,13 +38,118 @@ func Profile(as *authservice.AuthService) echo.HandlerFunc {
 			})
 		}
 
+		return c.JSON(200, &response{
+			Id:              user.Id,
+			LastName:        user.LastName,
+			FirstName:       user.FirstName,
+			MiddleName:      user.MiddleName,
+			Email:           user.Email,
+			EmailVerifiedAt: user.EmailVerifiedAt,
+			Roles:           roles,
+		})
+	}
+}
+
+func UpdateProfile(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		LastName   *string `json:"lastName"`
+		FirstName  *string `json:"firstName"`
+		MiddleName *string `json:"middleName"`
+	}
+
+	type response struct {
+		Id         string     `json:"id"`
+		LastName   *string    `json:"lastName"`
+		FirstName  *string    `json:"firstName"`
+		MiddleName *string    `json:"middleName"`
+		Email      string     `json:"email"`
+		UpdatedAt  *time.Time `json:"updatedAt"`
+	}
+
+	return func(c echo.Context) error {
+		claims := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		user, err := as.UpdateProfile(c.Request().Context(), &dto.UpdateProfile{
+			UserId:     claims.Id,
+			LastName:   req.LastName,
+			FirstName:  req.FirstName,
+			MiddleName: req.MiddleName,
+		})
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
 		return c.JSON(200, &response{
 			Id:         user.Id,
 			LastName:   user.LastName,
 			FirstName:  user.FirstName,
 			MiddleName: user.MiddleName,
 			Email:      user.Email,
-			Roles:      roles,
+			UpdatedAt:  user.UpdatedAt,
 		})
 	}
 }
+
+func ChangeEmail(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	return func(c echo.Context) error {
+		claims := c.Get(mw.USER).(*entity.User)
+
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.RequestEmailChange(c.Request().Context(), &dto.ChangeEmail{
+			UserId:   claims.Id,
+			Email:    req.Email,
+			Password: req.Password,
+		}); err != nil {
+			if errors.Is(err, authservice.ErrInvalidRequest) || errors.Is(err, authservice.ErrInvalidCredentials) ||
+				errors.Is(err, authservice.ErrEmailTaken) {
+				return responses.BadRequest(c, err)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(202, responses.Payload{"sent": true})
+	}
+}
+
+func ConfirmEmailChange(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.ConfirmEmailChange(c.Request().Context(), req.Token); err != nil {
+			if errors.Is(err, authservice.ErrEmailTaken) {
+				return responses.BadRequest(c, err)
+			}
+			if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrUserNotFound) ||
+				errors.Is(err, authservice.ErrUserDisabled) {
+				return responses.BadRequest(c, authservice.ErrTokenInvalid)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"changed": true})
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
 	mw "mzhn/auth/internal/middleware"
 	"mzhn/auth/internal/services/authservice"
 
@@ -24,8 +26,14 @@ func Refresh(as *authservice.AuthService) echo.HandlerFunc {
 			})
 		}
 
-		tokens, err := as.Refresh(c.Request().Context(), &dto.Refresh{RefreshToken: token.(string)})
+		tokens, err := as.Refresh(c.Request().Context(), &dto.Refresh{
+			RefreshToken: token.(string),
+			Device:       device(c),
+		})
 		if err != nil {
+			if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrTokenReused) {
+				return responses.Unauthorized(c)
+			}
 			return c.JSON(echo.ErrInternalServerError.Code, map[string]any{
 				"message": err.Error(),
 			})
@@ -3,7 +3,6 @@ package handlers
 import (
 	"errors"
 	"mzhn/auth/internal/dto"
-	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/responses"
 	"mzhn/auth/internal/services/authservice"
 
@@ -12,12 +11,13 @@ import (
 
 func Register(as *authservice.AuthService) echo.HandlerFunc {
 	type request struct {
-		LastName   *string       `json:"lastName"`
-		FirstName  *string       `json:"firstName"`
-		MiddleName *string       `json:"middleName"`
-		Email      string        `json:"email"`
-		Password   string        `json:"password"`
-		Roles      []entity.Role `json:"roles"`
+		LastName   *string `json:"lastName"`
+		FirstName  *string `json:"firstName"`
+		MiddleName *string `json:"middleName"`
+		Email      string  `json:"email"`
+		Password   string  `json:"password"`
+		Invite     string  `json:"invite"`
+		Audience   string  `json:"audience"`
 	}
 
 	type response struct {
@@ -38,15 +38,22 @@ func Register(as *authservice.AuthService) echo.HandlerFunc {
 			MiddleName: req.MiddleName,
 			Email:      req.Email,
 			Password:   req.Password,
-			Roles:      req.Roles,
+			Invite:     req.Invite,
+			Audience:   req.Audience,
+			Device:     device(c),
 		})
 		if err != nil {
-			if errors.Is(err, authservice.ErrEmailTaken) {
+			if errors.Is(err, authservice.ErrEmailTaken) || errors.Is(err, authservice.ErrAudienceInvalid) ||
+				errors.Is(err, authservice.ErrInviteInvalid) || errors.Is(err, authservice.ErrRoleNotFound) {
 				return responses.BadRequest(c, err)
 			}
 			return responses.Internal(c, err)
 		}
 
+		if tokens == nil {
+			return c.JSON(202, responses.Payload{"emailVerificationRequired": true})
+		}
+
 		return c.JSON(200, &response{
 			AccessToken:  tokens.AccessToken,
 			RefreshToken: tokens.RefreshToken,
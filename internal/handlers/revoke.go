@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Revoke(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		clientId, clientSecret, err := clientCredentials(c)
+		if err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		token := c.FormValue("token")
+		if token == "" {
+			return oauthError(c, authservice.ErrInvalidRequest)
+		}
+
+		if err := as.RevokeToken(c.Request().Context(), &dto.RevokeToken{
+			Token:         token,
+			TokenTypeHint: c.FormValue("token_type_hint"),
+			ClientId:      clientId,
+			ClientSecret:  clientSecret,
+		}); err != nil {
+			return oauthError(c, err)
+		}
+
+		// RFC 7009 section 2.2: invalid tokens are not an error
+		return c.NoContent(200)
+	}
+}
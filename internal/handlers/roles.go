@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Roles(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Roles []entity.RoleDefinition `json:"roles"`
+	}
+
+	return func(c echo.Context) error {
+		roles, err := as.Roles(c.Request().Context())
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{Roles: roles})
+	}
+}
+
+func Role(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		role, err := as.Role(c.Request().Context(), entity.Role(c.Param("name")))
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, role)
+	}
+}
+
+func CreateRole(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Name        entity.Role         `json:"name"`
+		Description *string             `json:"description"`
+		Permissions []entity.Permission `json:"permissions"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		role, err := as.CreateRole(c.Request().Context(), &dto.CreateRole{
+			Name:        req.Name,
+			Description: req.Description,
+			Permissions: req.Permissions,
+		})
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, role)
+	}
+}
+
+func UpdateRole(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Description *string             `json:"description"`
+		Permissions []entity.Permission `json:"permissions"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		role, err := as.UpdateRole(c.Request().Context(), &dto.UpdateRole{
+			Name:        entity.Role(c.Param("name")),
+			Description: req.Description,
+			Permissions: req.Permissions,
+		})
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, role)
+	}
+}
+
+func DeleteRole(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := as.DeleteRole(c.Request().Context(), entity.Role(c.Param("name"))); err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
+
+func Permissions(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Permissions []entity.PermissionDefinition `json:"permissions"`
+	}
+
+	return func(c echo.Context) error {
+		permissions, err := as.Permissions(c.Request().Context())
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, &response{Permissions: permissions})
+	}
+}
+
+func CreatePermission(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Name        entity.Permission `json:"name"`
+		Description *string           `json:"description"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		permission, err := as.CreatePermission(c.Request().Context(), &dto.CreatePermission{
+			Name:        req.Name,
+			Description: req.Description,
+		})
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, permission)
+	}
+}
+
+func DeletePermission(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := as.DeletePermission(c.Request().Context(), entity.Permission(c.Param("name"))); err != nil {
+			if errors.Is(err, authservice.ErrPermissionNotFound) {
+				return responses.NotFound(c)
+			}
+			return roleError(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
+
+// roleError answers 400 for a missing permission, it is referenced from the request body.
+func roleError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrRoleNotFound):
+		return responses.NotFound(c)
+	case errors.Is(err, authservice.ErrInvalidRequest),
+		errors.Is(err, authservice.ErrRoleExists),
+		errors.Is(err, authservice.ErrPermissionExists),
+		errors.Is(err, authservice.ErrPermissionNotFound):
+		return responses.BadRequest(c, err)
+	}
+	return responses.Internal(c, err)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Sessions(as *authservice.AuthService) echo.HandlerFunc {
+
+	type session struct {
+		Id         string    `json:"id"`
+		UserAgent  string    `json:"userAgent"`
+		Ip         string    `json:"ip"`
+		CreatedAt  time.Time `json:"createdAt"`
+		LastUsedAt time.Time `json:"lastUsedAt"`
+		Current    bool      `json:"current"`
+	}
+
+	type response struct {
+		Sessions []session `json:"sessions"`
+	}
+
+	return func(c echo.Context) error {
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
+		ctx := c.Request().Context()
+
+		sessions, err := as.Sessions(ctx, claims.Id)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		res := &response{Sessions: make([]session, 0, len(sessions))}
+		for _, s := range sessions {
+			res.Sessions = append(res.Sessions, session{
+				Id:         s.Id,
+				UserAgent:  s.UserAgent,
+				Ip:         s.Ip,
+				CreatedAt:  s.CreatedAt,
+				LastUsedAt: s.LastUsedAt,
+				Current:    s.Id == claims.SessionId,
+			})
+		}
+
+		return c.JSON(200, res)
+	}
+}
+
+func RevokeSession(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
+		ctx := c.Request().Context()
+
+		if err := as.RevokeSession(ctx, claims.Id, c.Param("id")); err != nil {
+			if errors.Is(err, authservice.ErrSessionNotFound) {
+				return responses.NotFound(c)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
+
+func RevokeOtherSessions(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
+		ctx := c.Request().Context()
+
+		if err := as.RevokeOtherSessions(ctx, claims.Id, claims.SessionId); err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return c.JSON(200, nil)
+	}
+}
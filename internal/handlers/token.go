@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func Token(as *authservice.AuthService) echo.HandlerFunc {
+
+	type response struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		IdToken      string `json:"id_token,omitempty"`
+		Scope        string `json:"scope,omitempty"`
+	}
+
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+		clientId, clientSecret, err := clientCredentials(c)
+		if err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		tokens, err := as.Token(c.Request().Context(), &dto.Token{
+			GrantType:    c.FormValue("grant_type"),
+			Code:         c.FormValue("code"),
+			RedirectUri:  c.FormValue("redirect_uri"),
+			ClientId:     clientId,
+			ClientSecret: clientSecret,
+			Scope:        c.FormValue("scope"),
+			CodeVerifier: c.FormValue("code_verifier"),
+			RefreshToken: c.FormValue("refresh_token"),
+			Device:       device(c),
+		})
+		if err != nil {
+			return oauthError(c, err)
+		}
+
+		return c.JSON(200, &response{
+			AccessToken:  tokens.AccessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    tokens.ExpiresIn,
+			RefreshToken: tokens.RefreshToken,
+			IdToken:      tokens.IdToken,
+			Scope:        tokens.Scope,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// Userinfo returns the claims granted to the access token. Tokens of OAuth clients carry
+// their scope, first party tokens have none and see every claim.
+func Userinfo(as *authservice.AuthService) echo.HandlerFunc {
+
+	type response struct {
+		Sub           string  `json:"sub"`
+		Email         string  `json:"email,omitempty"`
+		EmailVerified *bool   `json:"email_verified,omitempty"`
+		GivenName     *string `json:"given_name,omitempty"`
+		FamilyName    *string `json:"family_name,omitempty"`
+		MiddleName    *string `json:"middle_name,omitempty"`
+	}
+
+	return func(c echo.Context) error {
+		claims := c.Get(mw.CLAIMS).(*entity.UserClaims)
+		ctx := c.Request().Context()
+
+		granted := func(scope string) bool {
+			return len(claims.Scope) == 0 || lo.Contains(claims.Scope, scope)
+		}
+
+		if !granted(authservice.ScopeOpenId) {
+			return c.JSON(403, responses.Payload{"error": "insufficient_scope"})
+		}
+
+		user, _, err := as.Profile(ctx, claims.Id)
+		if err != nil {
+			return responses.Internal(c, err)
+		}
+
+		res := &response{Sub: user.Id}
+
+		if granted(authservice.ScopeEmail) {
+			verified := user.EmailVerifiedAt != nil
+			res.Email = user.Email
+			res.EmailVerified = &verified
+		}
+
+		if granted(authservice.ScopeProfile) {
+			res.GivenName = user.FirstName
+			res.FamilyName = user.LastName
+			res.MiddleName = user.MiddleName
+		}
+
+		return c.JSON(200, res)
+	}
+}
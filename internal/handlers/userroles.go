@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/responses"
+	mw "mzhn/auth/internal/middleware"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+type userRolesResponse struct {
+	UserId string        `json:"userId"`
+	Roles  []entity.Role `json:"roles"`
+}
+
+func UserRoles(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Param("id")
+
+		roles, err := as.UserRoles(c.Request().Context(), userId)
+		if err != nil {
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, &userRolesResponse{UserId: userId, Roles: roles})
+	}
+}
+
+func GrantRoles(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Roles []entity.Role `json:"roles"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		actor := c.Get(mw.USER).(*entity.User)
+		userId := c.Param("id")
+
+		roles, err := as.GrantRoles(c.Request().Context(), &dto.AddRoles{
+			UserId:         userId,
+			ActorId:        actor.Id,
+			OrganizationId: c.Param("org"),
+			Roles:          req.Roles,
+		})
+		if err != nil {
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, &userRolesResponse{UserId: userId, Roles: roles})
+	}
+}
+
+func RevokeRole(as *authservice.AuthService) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := c.Get(mw.USER).(*entity.User)
+		userId := c.Param("id")
+
+		roles, err := as.RevokeRoles(c.Request().Context(), &dto.RemoveRoles{
+			UserId:         userId,
+			ActorId:        actor.Id,
+			OrganizationId: c.Param("org"),
+			Roles:          []entity.Role{entity.Role(c.Param("role"))},
+		})
+		if err != nil {
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, &userRolesResponse{UserId: userId, Roles: roles})
+	}
+}
+
+func RoleAudit(as *authservice.AuthService) echo.HandlerFunc {
+	type response struct {
+		Audit []entity.RoleAudit `json:"audit"`
+	}
+
+	return func(c echo.Context) error {
+		records, err := as.RoleAudit(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return userRolesError(c, err)
+		}
+
+		return c.JSON(200, &response{Audit: records})
+	}
+}
+
+func userRolesError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, authservice.ErrUserNotFound), errors.Is(err, authservice.ErrOrganizationNotFound):
+		return responses.NotFound(c)
+	case errors.Is(err, authservice.ErrInsufficientPermission):
+		return responses.Forbidden(c)
+	case errors.Is(err, authservice.ErrInvalidRequest),
+		errors.Is(err, authservice.ErrRoleNotFound),
+		errors.Is(err, authservice.ErrNotMember),
+		errors.Is(err, authservice.ErrUserDisabled):
+		return responses.BadRequest(c, err)
+	}
+	return responses.Internal(c, err)
+}
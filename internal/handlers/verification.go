@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+
+	"mzhn/auth/internal/lib/responses"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/labstack/echo/v4"
+)
+
+func VerifyEmail(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if err := as.VerifyEmail(c.Request().Context(), req.Token); err != nil {
+			if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrUserNotFound) ||
+				errors.Is(err, authservice.ErrUserDisabled) {
+				return responses.BadRequest(c, authservice.ErrTokenInvalid)
+			}
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"verified": true})
+	}
+}
+
+func ResendVerification(as *authservice.AuthService) echo.HandlerFunc {
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(c echo.Context) error {
+		var req request
+
+		if err := c.Bind(&req); err != nil {
+			return responses.BadRequest(c, err)
+		}
+
+		if req.Email == "" {
+			return responses.BadRequest(c, authservice.ErrInvalidRequest)
+		}
+
+		if err := as.ResendVerification(c.Request().Context(), req.Email); err != nil {
+			return responses.Internal(c, err)
+		}
+
+		return responses.Ok(c, responses.Payload{"sent": true})
+	}
+}
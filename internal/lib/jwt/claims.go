@@ -1,11 +1,70 @@
 package jwt
 
 import (
+	"strings"
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"mzhn/auth/internal/entity"
 )
 
+func init() {
+	// revocation watermarks are compared with iat, in whole seconds a token refreshed
+	// right after a revocation would fall into the revoked second
+	jwt.TimePrecision = time.Millisecond
+}
+
 type claims struct {
-	entity.UserClaims
+	Email       string   `json:"email,omitempty"`
+	SessionId   string   `json:"sid,omitempty"`
+	OrgId       string   `json:"org_id,omitempty"`
+	ClientId    string   `json:"client_id,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Mfa         bool     `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Options describe who issues a token and whom it is meant for.
+// On verification the token must carry Issuer and at least one of Audience.
+type Options struct {
+	Issuer   string
+	Audience []string
+}
+
+func (c *claims) user() *entity.UserClaims {
+	user := &entity.UserClaims{
+		Id:             c.Subject,
+		Email:          c.Email,
+		SessionId:      c.SessionId,
+		OrganizationId: c.OrgId,
+		TokenId:        c.ID,
+		ClientId:       c.ClientId,
+		Scope:          strings.Fields(c.Scope),
+		Audience:       c.Audience,
+		Mfa:            c.Mfa,
+	}
+
+	for _, role := range c.Roles {
+		user.Roles = append(user.Roles, entity.Role(role))
+	}
+
+	for _, permission := range c.Permissions {
+		user.Permissions = append(user.Permissions, entity.Permission(permission))
+	}
+
+	if c.IssuedAt != nil {
+		user.IssuedAt = c.IssuedAt.Time
+	}
+
+	if c.NotBefore != nil {
+		user.NotBefore = c.NotBefore.Time
+	}
+
+	if c.ExpiresAt != nil {
+		user.ExpiresAt = c.ExpiresAt.Time
+	}
+
+	return user
+}
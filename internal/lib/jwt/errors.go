@@ -3,5 +3,6 @@ package jwt
 import "errors"
 
 var (
-	ErrTokenExpired = errors.New("token expired")
+	ErrTokenExpired    = errors.New("token expired")
+	ErrAudienceInvalid = errors.New("token audience invalid")
 )
@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// IdToken is the OpenID Connect identity of an authenticated user.
+// Optional claims are left out when empty.
+type IdToken struct {
+	Subject       string
+	Nonce         string
+	AuthTime      time.Time
+	Email         string
+	EmailVerified *bool
+	GivenName     *string
+	FamilyName    *string
+	MiddleName    *string
+}
+
+type idTokenClaims struct {
+	Nonce         string  `json:"nonce,omitempty"`
+	AuthTime      int64   `json:"auth_time"`
+	Email         string  `json:"email,omitempty"`
+	EmailVerified *bool   `json:"email_verified,omitempty"`
+	GivenName     *string `json:"given_name,omitempty"`
+	FamilyName    *string `json:"family_name,omitempty"`
+	MiddleName    *string `json:"middle_name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func SignIdToken(id *IdToken, ttl time.Duration, key *Key, opts Options) (string, error) {
+	now := time.Now()
+
+	payload := idTokenClaims{
+		Nonce:         id.Nonce,
+		AuthTime:      id.AuthTime.Unix(),
+		Email:         id.Email,
+		EmailVerified: id.EmailVerified,
+		GivenName:     id.GivenName,
+		FamilyName:    id.FamilyName,
+		MiddleName:    id.MiddleName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    opts.Issuer,
+			Subject:   id.Subject,
+			Audience:  opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(key.Method, payload)
+	if key.Id != "" {
+		token.Header["kid"] = key.Id
+	}
+
+	return token.SignedString(key.signKey)
+}
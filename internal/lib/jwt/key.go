@@ -0,0 +1,107 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a single signing key identified by its kid.
+// HMAC keys sign and verify with the same secret, asymmetric keys
+// sign with the private part and verify with the public one.
+type Key struct {
+	Id     string
+	Method jwt.SigningMethod
+
+	signKey   any
+	verifyKey any
+}
+
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{
+		Id:        kid,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// LoadKey reads a PEM encoded private key for alg from path.
+// If kid is empty it is derived from the public key.
+func LoadKey(kid, alg, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key %s: %w", path, err)
+	}
+
+	return ParseKey(kid, alg, data)
+}
+
+func ParseKey(kid, alg string, pemData []byte) (*Key, error) {
+	key := &Key{Id: kid}
+
+	switch alg {
+	case AlgRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodRS256, private, &private.PublicKey
+	case AlgES256:
+		private, err := jwt.ParseECPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+		if private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%s requires a P-256 key", alg)
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodES256, private, &private.PublicKey
+	case AlgEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+
+	if key.Id == "" {
+		kid, err := thumbprint(key.verifyKey)
+		if err != nil {
+			return nil, err
+		}
+		key.Id = kid
+	}
+
+	return key, nil
+}
+
+// Symmetric reports whether the key is a shared secret that must not be published.
+func (k *Key) Symmetric() bool {
+	_, ok := k.verifyKey.([]byte)
+	return ok
+}
+
+func thumbprint(public any) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal public key: %w", err)
+	}
+
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
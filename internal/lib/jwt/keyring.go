@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNoActiveKey     = errors.New("no active signing key")
+	ErrNoAsymmetricKey = errors.New("no active asymmetric signing key")
+)
+
+// KeySet resolves the key a token was signed with by its kid.
+type KeySet interface {
+	Lookup(kid string) (*Key, bool)
+}
+
+func (k *Key) Lookup(kid string) (*Key, bool) {
+	return k, kid == k.Id
+}
+
+// RingKey is a key scheduled in a Keyring. It signs from ActivateAt until a
+// newer key activates and is accepted for verification until RetireAt.
+type RingKey struct {
+	*Key
+	ActivateAt time.Time
+	RetireAt   time.Time
+}
+
+func (k *RingKey) retired(now time.Time) bool {
+	return !k.RetireAt.IsZero() && !now.Before(k.RetireAt)
+}
+
+// Keyring holds one active signing key and any number of verification-only
+// keys, so signing keys can be rotated without invalidating issued tokens.
+type Keyring struct {
+	keys []*RingKey
+}
+
+func NewKeyring(keys ...*RingKey) (*Keyring, error) {
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k.Id]; ok {
+			return nil, fmt.Errorf("duplicate key id: %q", k.Id)
+		}
+		seen[k.Id] = struct{}{}
+	}
+
+	sorted := make([]*RingKey, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivateAt.After(sorted[j].ActivateAt)
+	})
+
+	return &Keyring{keys: sorted}, nil
+}
+
+// Active returns the most recently activated key that is not retired yet.
+func (r *Keyring) Active() (*Key, error) {
+	now := time.Now()
+	for _, k := range r.keys {
+		if k.ActivateAt.After(now) || k.retired(now) {
+			continue
+		}
+		return k.Key, nil
+	}
+
+	return nil, ErrNoActiveKey
+}
+
+// ActiveAsymmetric returns the most recently activated asymmetric key that is not retired yet,
+// for tokens verified by third parties through the JWKS.
+func (r *Keyring) ActiveAsymmetric() (*Key, error) {
+	now := time.Now()
+	for _, k := range r.keys {
+		if k.ActivateAt.After(now) || k.retired(now) || k.Symmetric() {
+			continue
+		}
+		return k.Key, nil
+	}
+
+	return nil, ErrNoAsymmetricKey
+}
+
+func (r *Keyring) Lookup(kid string) (*Key, bool) {
+	now := time.Now()
+	for _, k := range r.keys {
+		if k.Id == kid && !k.retired(now) {
+			return k.Key, true
+		}
+	}
+
+	return nil, false
+}
+
+// JWKS publishes every asymmetric key that is not retired, including keys
+// scheduled for the future, so verifiers learn them before first use.
+func (r *Keyring) JWKS() *JWKS {
+	now := time.Now()
+	jwks := &JWKS{Keys: make([]JWK, 0, len(r.keys))}
+	for _, k := range r.keys {
+		if k.retired(now) {
+			continue
+		}
+		if jwk, ok := k.JWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+// Algs lists the signing algorithms of asymmetric keys that are not retired,
+// which are the ones third parties can verify.
+func (r *Keyring) Algs() []string {
+	now := time.Now()
+	algs := make([]string, 0, len(r.keys))
+	for _, k := range r.keys {
+		if k.retired(now) || k.Symmetric() || slices.Contains(algs, k.Method.Alg()) {
+			continue
+		}
+		algs = append(algs, k.Method.Alg())
+	}
+
+	return algs
+}
+
+type keyringFile struct {
+	Keys []struct {
+		Id         string    `json:"kid"`
+		Alg        string    `json:"alg"`
+		Path       string    `json:"path"`
+		Secret     string    `json:"secret"`
+		ActivateAt time.Time `json:"activateAt"`
+		RetireAt   time.Time `json:"retireAt"`
+	} `json:"keys"`
+}
+
+// LoadKeyring reads a JSON keyring description from path, e.g.
+//
+//	{"keys": [
+//	  {"kid": "2024-09", "alg": "ES256", "path": "keys/2024-09.pem", "activateAt": "2024-09-01T00:00:00Z", "retireAt": "2024-10-02T00:00:00Z"},
+//	  {"kid": "2024-10", "alg": "ES256", "path": "keys/2024-10.pem", "activateAt": "2024-10-01T00:00:00Z"}
+//	]}
+//
+// HS256 keys take a secret instead of a path.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keyring %s: %w", path, err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse keyring %s: %w", path, err)
+	}
+
+	keys := make([]*RingKey, 0, len(file.Keys))
+	for _, k := range file.Keys {
+		var key *Key
+
+		if k.Alg == AlgHS256 {
+			if k.Secret == "" {
+				return nil, fmt.Errorf("key %q: secret is required for %s", k.Id, AlgHS256)
+			}
+			key = NewHMACKey(k.Id, []byte(k.Secret))
+		} else {
+			key, err = LoadKey(k.Id, k.Alg, k.Path)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Id, err)
+			}
+		}
+
+		keys = append(keys, &RingKey{
+			Key:        key,
+			ActivateAt: k.ActivateAt,
+			RetireAt:   k.RetireAt,
+		})
+	}
+
+	return NewKeyring(keys...)
+}
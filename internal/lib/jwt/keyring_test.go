@@ -0,0 +1,322 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/entity"
+)
+
+func newES256Key(t *testing.T, kid string) (*Key, []byte) {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	key, err := ParseKey(kid, AlgES256, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, data
+}
+
+func TestKeyringActive(t *testing.T) {
+	now := time.Now()
+	hour := time.Hour
+
+	tests := []struct {
+		name   string
+		keys   []*RingKey
+		active string
+		err    error
+	}{
+		{
+			name:   "single key",
+			keys:   []*RingKey{{Key: NewHMACKey("a", []byte("a"))}},
+			active: "a",
+		},
+		{
+			name: "newest activated key signs",
+			keys: []*RingKey{
+				{Key: NewHMACKey("old", []byte("old")), ActivateAt: now.Add(-2 * hour)},
+				{Key: NewHMACKey("new", []byte("new")), ActivateAt: now.Add(-hour)},
+			},
+			active: "new",
+		},
+		{
+			name: "scheduled key does not sign yet",
+			keys: []*RingKey{
+				{Key: NewHMACKey("current", []byte("current")), ActivateAt: now.Add(-hour)},
+				{Key: NewHMACKey("next", []byte("next")), ActivateAt: now.Add(hour)},
+			},
+			active: "current",
+		},
+		{
+			name: "retired key does not sign",
+			keys: []*RingKey{
+				{Key: NewHMACKey("fallback", []byte("fallback")), ActivateAt: now.Add(-2 * hour)},
+				{Key: NewHMACKey("retired", []byte("retired")), ActivateAt: now.Add(-hour), RetireAt: now.Add(-time.Minute)},
+			},
+			active: "fallback",
+		},
+		{
+			name: "every key retired or scheduled",
+			keys: []*RingKey{
+				{Key: NewHMACKey("retired", []byte("retired")), RetireAt: now.Add(-time.Minute)},
+				{Key: NewHMACKey("next", []byte("next")), ActivateAt: now.Add(hour)},
+			},
+			err: ErrNoActiveKey,
+		},
+		{
+			name: "empty keyring",
+			err:  ErrNoActiveKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewKeyring(tt.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := ring.Active()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && key.Id != tt.active {
+				t.Fatalf("Active() = %q, want %q", key.Id, tt.active)
+			}
+		})
+	}
+}
+
+func TestKeyringLookup(t *testing.T) {
+	now := time.Now()
+
+	ring, err := NewKeyring(
+		&RingKey{Key: NewHMACKey("retired", []byte("retired")), ActivateAt: now.Add(-2 * time.Hour), RetireAt: now.Add(-time.Minute)},
+		&RingKey{Key: NewHMACKey("previous", []byte("previous")), ActivateAt: now.Add(-time.Hour), RetireAt: now.Add(time.Hour)},
+		&RingKey{Key: NewHMACKey("current", []byte("current")), ActivateAt: now.Add(-time.Minute)},
+		&RingKey{Key: NewHMACKey("next", []byte("next")), ActivateAt: now.Add(time.Hour)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kid   string
+		found bool
+	}{
+		{"retired", false},
+		{"previous", true},
+		{"current", true},
+		{"next", true},
+		{"unknown", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kid, func(t *testing.T) {
+			key, ok := ring.Lookup(tt.kid)
+			if ok != tt.found {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.kid, ok, tt.found)
+			}
+			if ok && key.Id != tt.kid {
+				t.Fatalf("Lookup(%q) = %q", tt.kid, key.Id)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	opts := Options{Issuer: "auth", Audience: []string{"auth"}}
+	user := &entity.UserClaims{Id: "user-1"}
+
+	old := NewHMACKey("old", []byte("old"))
+	before, err := NewKeyring(&RingKey{Key: old, ActivateAt: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := before.Active()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := Sign(user, time.Hour, signer, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new key took over, the old one still verifies what it signed
+	next, _ := newES256Key(t, "new")
+	rotated, err := NewKeyring(
+		&RingKey{Key: old, ActivateAt: now.Add(-time.Hour), RetireAt: now.Add(time.Hour)},
+		&RingKey{Key: next, ActivateAt: now.Add(-time.Minute)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active, _ := rotated.Active(); active.Id != "new" {
+		t.Fatalf("Active() = %q, want %q", active.Id, "new")
+	}
+
+	if _, err := Verify(token, rotated, opts); err != nil {
+		t.Fatalf("token of the previous key: %v", err)
+	}
+
+	retired, err := NewKeyring(
+		&RingKey{Key: old, ActivateAt: now.Add(-time.Hour), RetireAt: now.Add(-time.Second)},
+		&RingKey{Key: next, ActivateAt: now.Add(-time.Minute)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(token, retired, opts); err == nil {
+		t.Fatal("token of a retired key verified")
+	}
+
+	// a kid must not let a token pick another key of the ring
+	forged, err := Sign(user, time.Hour, &Key{Id: "new", Method: old.Method, signKey: []byte("old")}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(forged, rotated, opts); err == nil {
+		t.Fatal("token verified with the algorithm of another key")
+	}
+}
+
+func TestKeyringAsymmetric(t *testing.T) {
+	now := time.Now()
+	es, _ := newES256Key(t, "es")
+
+	ring, err := NewKeyring(
+		&RingKey{Key: es, ActivateAt: now.Add(-time.Hour)},
+		&RingKey{Key: NewHMACKey("hs", []byte("hs")), ActivateAt: now.Add(-time.Minute)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active, _ := ring.Active(); active.Id != "hs" {
+		t.Fatalf("Active() = %q, want %q", active.Id, "hs")
+	}
+
+	if active, err := ring.ActiveAsymmetric(); err != nil || active.Id != "es" {
+		t.Fatalf("ActiveAsymmetric() = %v, %v, want %q", active, err, "es")
+	}
+
+	if algs := ring.Algs(); len(algs) != 1 || algs[0] != AlgES256 {
+		t.Fatalf("Algs() = %v, want [%s]", algs, AlgES256)
+	}
+
+	if jwks := ring.JWKS(); len(jwks.Keys) != 1 {
+		t.Fatalf("JWKS() published %d keys, want the asymmetric one only", len(jwks.Keys))
+	}
+
+	symmetric, err := NewKeyring(&RingKey{Key: NewHMACKey("hs", []byte("hs"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := symmetric.ActiveAsymmetric(); !errors.Is(err, ErrNoAsymmetricKey) {
+		t.Fatalf("err = %v, want %v", err, ErrNoAsymmetricKey)
+	}
+}
+
+func TestNewKeyringDuplicateKid(t *testing.T) {
+	if _, err := NewKeyring(
+		&RingKey{Key: NewHMACKey("a", []byte("a"))},
+		&RingKey{Key: NewHMACKey("a", []byte("b"))},
+	); err == nil {
+		t.Fatal("duplicate kid accepted")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+
+	_, data := newES256Key(t, "")
+	if err := os.WriteFile(filepath.Join(dir, "es.pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+
+		path := filepath.Join(dir, t.Name()+".json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		path := write(t, fmt.Sprintf(`{"keys": [
+			{"kid": "hs", "alg": "HS256", "secret": "secret", "activateAt": %q, "retireAt": %q},
+			{"kid": "es", "alg": "ES256", "path": %q, "activateAt": %q}
+		]}`,
+			now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339),
+			filepath.Join(dir, "es.pem"), now.Add(-time.Minute).Format(time.RFC3339)))
+
+		ring, err := LoadKeyring(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if active, _ := ring.Active(); active.Id != "es" {
+			t.Fatalf("Active() = %q, want %q", active.Id, "es")
+		}
+
+		if _, ok := ring.Lookup("hs"); !ok {
+			t.Fatal("previous key not found")
+		}
+	})
+
+	t.Run("HS256 without secret", func(t *testing.T) {
+		path := write(t, `{"keys": [{"kid": "hs", "alg": "HS256"}]}`)
+
+		if _, err := LoadKeyring(path); err == nil {
+			t.Fatal("HS256 key without secret accepted")
+		}
+	})
+
+	t.Run("duplicate kid", func(t *testing.T) {
+		path := write(t, `{"keys": [
+			{"kid": "hs", "alg": "HS256", "secret": "a"},
+			{"kid": "hs", "alg": "HS256", "secret": "b"}
+		]}`)
+
+		if _, err := LoadKeyring(path); err == nil {
+			t.Fatal("duplicate kid accepted")
+		}
+	})
+}
@@ -1,24 +1,49 @@
 package jwt
 
 import (
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"mzhn/auth/internal/entity"
 )
 
-func Sign(user *entity.UserClaims, ttl time.Duration, secret []byte) (string, error) {
+func Sign(user *entity.UserClaims, ttl time.Duration, key *Key, opts Options) (string, error) {
+	now := time.Now()
+
 	payload := claims{
-		UserClaims: *user,
+		Email:     user.Email,
+		SessionId: user.SessionId,
+		OrgId:     user.OrganizationId,
+		ClientId:  user.ClientId,
+		Scope:     strings.Join(user.Scope, " "),
+		Mfa:       user.Mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Issuer:    opts.Issuer,
+			Subject:   user.Id,
+			Audience:  opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	for _, role := range user.Roles {
+		payload.Roles = append(payload.Roles, role.String())
+	}
+
+	for _, permission := range user.Permissions {
+		payload.Permissions = append(payload.Permissions, permission.String())
+	}
+
+	token := jwt.NewWithClaims(key.Method, payload)
+	if key.Id != "" {
+		token.Header["kid"] = key.Id
+	}
 
-	signed, err := token.SignedString(secret)
+	signed, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,37 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/entity"
+)
+
+func TestSignIssuedAtMilliseconds(t *testing.T) {
+	opts := Options{Issuer: "auth", Audience: []string{"auth"}}
+	key := NewHMACKey("", []byte("secret"))
+
+	for i := 0; i < 20; i++ {
+		before := time.Now()
+
+		token, err := Sign(&entity.UserClaims{Id: "user-1"}, time.Minute, key, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		after := time.Now()
+
+		claims, err := Verify(token, key, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// decoding the fractional seconds may lose up to a millisecond
+		low, high := before.Truncate(time.Millisecond).Add(-time.Millisecond), after
+		if claims.IssuedAt.Before(low) || claims.IssuedAt.After(high) {
+			t.Fatalf("iat = %s, want within [%s, %s]", claims.IssuedAt.Format(time.RFC3339Nano), low.Format(time.RFC3339Nano), high.Format(time.RFC3339Nano))
+		}
+
+		time.Sleep(3 * time.Millisecond)
+	}
+}
@@ -5,16 +5,25 @@ import (
 	"fmt"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/samber/lo"
 	"mzhn/auth/internal/entity"
 )
 
-func Verify(tokenString string, secret string) (*entity.UserClaims, error) {
+func Verify(tokenString string, keys KeySet, opts Options) (*entity.UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
-	}, jwt.WithExpirationRequired())
+
+		return key.verifyKey, nil
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt(), jwt.WithIssuer(opts.Issuer))
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrTokenExpired
@@ -27,5 +36,13 @@ func Verify(tokenString string, secret string) (*entity.UserClaims, error) {
 		return nil, errors.New("unable to parse claims")
 	}
 
-	return &claims.UserClaims, nil
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	if len(opts.Audience) > 0 && !lo.Some(claims.Audience, opts.Audience) {
+		return nil, ErrAudienceInvalid
+	}
+
+	return claims.user(), nil
 }
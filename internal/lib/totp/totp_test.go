@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// secret of the SHA1 test vectors in RFC 6238 appendix B
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	// the RFC lists 8 digit values, authenticator apps show their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := Code(rfcSecret, Step(time.Unix(tt.unix, 0))); got != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// the first second of a step and the last one
+	start := time.Unix(1111111110, 0)
+	end := start.Add(Period - time.Second)
+	step := Step(start)
+
+	if Step(end) != step {
+		t.Fatalf("Step(%d) = %d, want %d", end.Unix(), Step(end), step)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		skew int
+		step int64
+		ok   bool
+	}{
+		{"current step", Code(rfcSecret, step), start, 1, step, true},
+		{"current step at its end", Code(rfcSecret, step), end, 1, step, true},
+		{"previous step", Code(rfcSecret, step-1), start, 1, step - 1, true},
+		{"next step", Code(rfcSecret, step+1), end, 1, step + 1, true},
+		{"two steps behind", Code(rfcSecret, step-2), start, 1, 0, false},
+		{"two steps ahead", Code(rfcSecret, step+2), end, 1, 0, false},
+		{"previous step without skew", Code(rfcSecret, step-1), start, 0, 0, false},
+		{"code of the next step once it began", Code(rfcSecret, step+1), end.Add(time.Second), 0, step + 1, true},
+		{"code of the step once it ended", Code(rfcSecret, step), end.Add(time.Second), 0, 0, false},
+		{"wrong code", "000000", start, 1, 0, false},
+		{"too short", Code(rfcSecret, step)[:Digits-1], start, 1, 0, false},
+		{"too long", Code(rfcSecret, step) + "0", start, 1, 0, false},
+		{"empty", "", start, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Validate(rfcSecret, tt.code, tt.at, tt.skew)
+			if ok != tt.ok || got != tt.step {
+				t.Fatalf("Validate() = %d, %v, want %d, %v", got, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
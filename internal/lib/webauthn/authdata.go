@@ -0,0 +1,76 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent   byte = 0x01
+	flagUserVerified  byte = 0x04
+	flagAttestedData  byte = 0x40
+	flagExtensionData byte = 0x80
+)
+
+var errMalformedAuthData = errors.New("malformed authenticator data")
+
+type authenticatorData struct {
+	rpIdHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialId []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errMalformedAuthData
+	}
+
+	ad := &authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[37:]
+
+	if ad.flags&flagAttestedData != 0 {
+		// aaguid (16) and credential id length (2)
+		if len(rest) < 18 {
+			return nil, errMalformedAuthData
+		}
+
+		size := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if size == 0 || size > 1023 || len(rest) < size {
+			return nil, errMalformedAuthData
+		}
+
+		ad.credentialId = rest[:size]
+		rest = rest[size:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		ad.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+
+	if ad.flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = after
+	}
+
+	if len(rest) > 0 {
+		return nil, errMalformedAuthData
+	}
+
+	return ad, nil
+}
@@ -0,0 +1,123 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) accepted for credentials.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters.
+const (
+	coseKty    int64 = 1
+	coseAlg    int64 = 3
+	coseCrv    int64 = -1
+	coseX      int64 = -2
+	coseY      int64 = -3
+	coseRsaN   int64 = -1
+	coseRsaE   int64 = -2
+	ktyOKP     int64 = 1
+	ktyEC2     int64 = 2
+	ktyRSA     int64 = 3
+	crvP256    int64 = 1
+	crvEd25519 int64 = 6
+)
+
+var ErrUnsupportedKey = errors.New("unsupported public key")
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key as found in attested credential data.
+func parsePublicKey(cose []byte) (*publicKey, error) {
+	decoded, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrUnsupportedKey)
+	}
+
+	params, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a map", ErrUnsupportedKey)
+	}
+
+	kty, _ := params[coseKty].(int64)
+	alg, _ := params[coseAlg].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := params[coseCrv].(int64)
+		x, _ := params[coseX].([]byte)
+		y, _ := params[coseY].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("%w: malformed EC2 key", ErrUnsupportedKey)
+		}
+
+		// rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedKey, err)
+		}
+
+		return &publicKey{alg: alg, key: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := params[coseCrv].(int64)
+		x, _ := params[coseX].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: malformed OKP key", ErrUnsupportedKey)
+		}
+
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := params[coseRsaN].([]byte)
+		e, _ := params[coseRsaE].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: malformed RSA key", ErrUnsupportedKey)
+		}
+
+		return &publicKey{alg: alg, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("%w: kty %d alg %d", ErrUnsupportedKey, kty, alg)
+}
+
+func (k *publicKey) verify(data, signature []byte) bool {
+	switch k.alg {
+	case AlgES256:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(k.key.(*ecdsa.PublicKey), digest[:], signature)
+	case AlgEdDSA:
+		return ed25519.Verify(k.key.(ed25519.PublicKey), data, signature)
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k.key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
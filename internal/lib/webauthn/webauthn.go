@@ -0,0 +1,354 @@
+// Package webauthn implements the relying party side of WebAuthn registration and authentication
+// ceremonies (https://www.w3.org/TR/webauthn-2/). Attestation statements are not verified, credentials
+// are trusted on first use, which is what passkeys created with attestation "none" provide anyway.
+//
+// The ceremonies are plain functions of the challenge and the client response, so they can be
+// driven by a software authenticator without a browser.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	ChallengeSize = 32
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid webauthn response")
+	ErrSignCount       = errors.New("signature counter did not increase, the authenticator may be cloned")
+)
+
+// Bytes is a byte slice encoded as unpadded base64url in JSON, as browsers serialize credentials.
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+type RelyingParty struct {
+	Id      string
+	Name    string
+	Origins []string
+	Timeout time.Duration
+}
+
+type User struct {
+	Id          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential is what has to be stored after a successful registration.
+type Credential struct {
+	Id        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+type rpEntity struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	Id          Bytes  `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	Id   Bytes  `json:"id"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	RequireResident  bool   `json:"requireResidentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are the PublicKeyCredentialCreationOptions passed to navigator.credentials.create.
+type CreationOptions struct {
+	Challenge              Bytes                  `json:"challenge"`
+	Rp                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the PublicKeyCredentialRequestOptions passed to navigator.credentials.get.
+type RequestOptions struct {
+	Challenge        Bytes                  `json:"challenge"`
+	RpId             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    Bytes `json:"clientDataJSON"`
+	AttestationObject Bytes `json:"attestationObject"`
+}
+
+// RegistrationCredential is the JSON form of the PublicKeyCredential returned by navigator.credentials.create.
+type RegistrationCredential struct {
+	Id       string              `json:"id"`
+	RawId    Bytes               `json:"rawId"`
+	Type     string              `json:"type"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AssertionResponse struct {
+	ClientDataJSON    Bytes `json:"clientDataJSON"`
+	AuthenticatorData Bytes `json:"authenticatorData"`
+	Signature         Bytes `json:"signature"`
+	UserHandle        Bytes `json:"userHandle"`
+}
+
+// AssertionCredential is the JSON form of the PublicKeyCredential returned by navigator.credentials.get.
+type AssertionCredential struct {
+	Id       string            `json:"id"`
+	RawId    Bytes             `json:"rawId"`
+	Type     string            `json:"type"`
+	Response AssertionResponse `json:"response"`
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// Challenge extracts the challenge the client signed, so the ceremony state can be looked up
+// before the response is verified.
+func Challenge(clientDataJSON []byte) ([]byte, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return nil, fmt.Errorf("%w: client data: %w", ErrInvalidResponse, err)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: challenge: %w", ErrInvalidResponse, err)
+	}
+
+	return challenge, nil
+}
+
+func (rp *RelyingParty) CreationOptions(user User, challenge []byte, exclude [][]byte) *CreationOptions {
+	return &CreationOptions{
+		Challenge: challenge,
+		Rp:        rpEntity{Id: rp.Id, Name: rp.Name},
+		User: userEntity{
+			Id:          user.Id,
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams: []credentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "required",
+			RequireResident:  true,
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions with no allowed credentials ask for a discoverable credential, the user is not known up front.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow [][]byte) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		RpId:             rp.Id,
+		Timeout:          rp.Timeout.Milliseconds(),
+		AllowCredentials: descriptors(allow),
+		UserVerification: "required",
+	}
+}
+
+// VerifyRegistration checks the response to CreationOptions issued with the challenge.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, cred *RegistrationCredential) (*Credential, error) {
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCBOR(cred.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %w", ErrInvalidResponse, err)
+	}
+
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object is not a map", ErrInvalidResponse)
+	}
+
+	raw, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: no authenticator data", ErrInvalidResponse)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.credentialId == nil {
+		return nil, fmt.Errorf("%w: no attested credential", ErrInvalidResponse)
+	}
+
+	if len(cred.RawId) > 0 && !bytes.Equal(cred.RawId, authData.credentialId) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	return &Credential{
+		Id:        authData.credentialId,
+		PublicKey: authData.publicKey,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion checks the response to RequestOptions issued with the challenge against a stored credential.
+// It returns the new signature counter to store.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, cred *AssertionCredential, stored *Credential) (uint32, error) {
+	if !bytes.Equal(cred.RawId, stored.Id) {
+		return 0, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyGet, challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := rp.verifyAuthenticatorData(cred.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(stored.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(cred.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), cred.Response.AuthenticatorData...), clientDataHash[:]...)
+
+	if !key.verify(signed, cred.Response.Signature) {
+		return 0, fmt.Errorf("%w: bad signature", ErrInvalidResponse)
+	}
+
+	// authenticators that do not count always report zero
+	if (authData.signCount != 0 || stored.SignCount != 0) && authData.signCount <= stored.SignCount {
+		return 0, ErrSignCount
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("%w: client data: %w", ErrInvalidResponse, err)
+	}
+
+	if cd.Type != ceremony {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidResponse, cd.Type)
+	}
+
+	signed, err := Challenge(raw)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(signed, challenge) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidResponse)
+	}
+
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return fmt.Errorf("%w: unexpected origin %q", ErrInvalidResponse, cd.Origin)
+	}
+
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	rpIdHash := sha256.Sum256([]byte(rp.Id))
+	if !bytes.Equal(authData.rpIdHash, rpIdHash[:]) {
+		return nil, fmt.Errorf("%w: relying party mismatch", ErrInvalidResponse)
+	}
+
+	if authData.flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("%w: user not present", ErrInvalidResponse)
+	}
+
+	if authData.flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user not verified", ErrInvalidResponse)
+	}
+
+	return authData, nil
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	result := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, CredentialDescriptor{Type: "public-key", Id: id})
+	}
+	return result
+}
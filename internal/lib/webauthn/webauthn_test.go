@@ -0,0 +1,514 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testRpId   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func testRelyingParty() *RelyingParty {
+	return &RelyingParty{
+		Id:      testRpId,
+		Name:    "Example",
+		Origins: []string{testOrigin},
+	}
+}
+
+// authenticator is a software authenticator holding a single discoverable credential.
+type authenticator struct {
+	alg       int64
+	signer    crypto.Signer
+	id        []byte
+	userId    []byte
+	signCount uint32
+	counting  bool
+
+	rpId   string
+	origin string
+	flags  byte
+}
+
+func newAuthenticator(t *testing.T, alg int64) *authenticator {
+	t.Helper()
+
+	var (
+		signer crypto.Signer
+		err    error
+	)
+
+	switch alg {
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported alg %d", alg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+
+	return &authenticator{
+		alg:      alg,
+		signer:   signer,
+		id:       id,
+		userId:   []byte("user-1"),
+		counting: true,
+		rpId:     testRpId,
+		origin:   testOrigin,
+		flags:    flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *authenticator) coseKey() []byte {
+	switch a.alg {
+	case AlgES256:
+		pub := a.signer.Public().(*ecdsa.PublicKey)
+		return cborMap(
+			coseKty, ktyEC2,
+			coseAlg, AlgES256,
+			coseCrv, crvP256,
+			coseX, pub.X.FillBytes(make([]byte, 32)),
+			coseY, pub.Y.FillBytes(make([]byte, 32)),
+		)
+	default:
+		return cborMap(
+			coseKty, ktyOKP,
+			coseAlg, AlgEdDSA,
+			coseCrv, crvEd25519,
+			coseX, []byte(a.signer.Public().(ed25519.PublicKey)),
+		)
+	}
+}
+
+func (a *authenticator) authData(flags byte, attested []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.rpId))
+
+	data := append([]byte(nil), rpIdHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func (a *authenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, _ := json.Marshal(clientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	return data
+}
+
+func (a *authenticator) create(challenge []byte) *RegistrationCredential {
+	attested := make([]byte, 16) // zero aaguid
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, a.coseKey()...)
+
+	attestation := cborMap(
+		"fmt", "none",
+		"attStmt", cborRaw(cborMap()),
+		"authData", a.authData(a.flags|flagAttestedData, attested),
+	)
+
+	return &RegistrationCredential{
+		Id:    base64.RawURLEncoding.EncodeToString(a.id),
+		RawId: a.id,
+		Type:  "public-key",
+		Response: AttestationResponse{
+			ClientDataJSON:    a.clientData(ceremonyCreate, challenge),
+			AttestationObject: attestation,
+		},
+	}
+}
+
+func (a *authenticator) get(t *testing.T, challenge []byte) *AssertionCredential {
+	t.Helper()
+
+	if a.counting {
+		a.signCount++
+	}
+
+	authData := a.authData(a.flags, nil)
+	clientDataJSON := a.clientData(ceremonyGet, challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+
+	var (
+		signature []byte
+		err       error
+	)
+	if a.alg == AlgES256 {
+		digest := sha256.Sum256(signed)
+		signature, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	} else {
+		signature, err = a.signer.Sign(rand.Reader, signed, crypto.Hash(0))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &AssertionCredential{
+		Id:    base64.RawURLEncoding.EncodeToString(a.id),
+		RawId: a.id,
+		Type:  "public-key",
+		Response: AssertionResponse{
+			ClientDataJSON:    clientDataJSON,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        a.userId,
+		},
+	}
+}
+
+func challenge(t *testing.T) []byte {
+	t.Helper()
+
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+// register runs a registration ceremony that is expected to succeed.
+func register(t *testing.T, rp *RelyingParty, a *authenticator) *Credential {
+	t.Helper()
+
+	c := challenge(t)
+	credential, err := rp.VerifyRegistration(c, a.create(c))
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	return credential
+}
+
+func TestCeremonies(t *testing.T) {
+	for name, alg := range map[string]int64{"ES256": AlgES256, "EdDSA": AlgEdDSA} {
+		t.Run(name, func(t *testing.T) {
+			rp := testRelyingParty()
+			a := newAuthenticator(t, alg)
+
+			credential := register(t, rp, a)
+			if !bytes.Equal(credential.Id, a.id) {
+				t.Fatalf("credential id = %x, want %x", credential.Id, a.id)
+			}
+
+			for i := 0; i < 2; i++ {
+				c := challenge(t)
+
+				// the response goes through JSON as it does coming from a browser
+				raw, err := json.Marshal(a.get(t, c))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var assertion AssertionCredential
+				if err := json.Unmarshal(raw, &assertion); err != nil {
+					t.Fatal(err)
+				}
+
+				signed, err := Challenge(assertion.Response.ClientDataJSON)
+				if err != nil || !bytes.Equal(signed, c) {
+					t.Fatalf("Challenge() = %x, %v, want %x", signed, err, c)
+				}
+
+				signCount, err := rp.VerifyAssertion(c, &assertion, credential)
+				if err != nil {
+					t.Fatalf("assertion %d failed: %v", i, err)
+				}
+				if signCount != a.signCount {
+					t.Fatalf("sign count = %d, want %d", signCount, a.signCount)
+				}
+				credential.SignCount = signCount
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(a *authenticator)
+	}{
+		{"wrong origin", func(a *authenticator) { a.origin = "https://evil.example" }},
+		{"wrong rp id hash", func(a *authenticator) { a.rpId = "evil.example" }},
+		{"user not verified", func(a *authenticator) { a.flags = flagUserPresent }},
+		{"user not present", func(a *authenticator) { a.flags = flagUserVerified }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthenticator(t, AlgES256)
+			tt.modify(a)
+
+			c := challenge(t)
+			if _, err := testRelyingParty().VerifyRegistration(c, a.create(c)); !errors.Is(err, ErrInvalidResponse) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidResponse)
+			}
+		})
+	}
+
+	t.Run("other challenge", func(t *testing.T) {
+		a := newAuthenticator(t, AlgES256)
+		if _, err := testRelyingParty().VerifyRegistration(challenge(t), a.create(challenge(t))); !errors.Is(err, ErrInvalidResponse) {
+			t.Fatalf("err = %v, want %v", err, ErrInvalidResponse)
+		}
+	})
+
+	t.Run("assertion as registration", func(t *testing.T) {
+		a := newAuthenticator(t, AlgES256)
+		c := challenge(t)
+		cred := a.create(c)
+		cred.Response.ClientDataJSON = a.clientData(ceremonyGet, c)
+		if _, err := testRelyingParty().VerifyRegistration(c, cred); !errors.Is(err, ErrInvalidResponse) {
+			t.Fatalf("err = %v, want %v", err, ErrInvalidResponse)
+		}
+	})
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		before func(a *authenticator)
+		after  func(cred *AssertionCredential)
+		want   error
+	}{
+		{
+			name:   "wrong origin",
+			before: func(a *authenticator) { a.origin = "https://evil.example" },
+			want:   ErrInvalidResponse,
+		},
+		{
+			name:   "wrong rp id hash",
+			before: func(a *authenticator) { a.rpId = "evil.example" },
+			want:   ErrInvalidResponse,
+		},
+		{
+			name:   "user not verified",
+			before: func(a *authenticator) { a.flags = flagUserPresent },
+			want:   ErrInvalidResponse,
+		},
+		{
+			name:   "counter regression",
+			before: func(a *authenticator) { a.signCount = 3 },
+			want:   ErrSignCount,
+		},
+		{
+			name:   "counter replay",
+			before: func(a *authenticator) { a.signCount = 4 },
+			want:   ErrSignCount,
+		},
+		{
+			name:   "counter dropped to zero",
+			before: func(a *authenticator) { a.signCount, a.counting = 0, false },
+			want:   ErrSignCount,
+		},
+		{
+			name:  "tampered signature",
+			after: func(cred *AssertionCredential) { cred.Response.Signature[len(cred.Response.Signature)-1] ^= 0xff },
+			want:  ErrInvalidResponse,
+		},
+		{
+			name:  "other credential",
+			after: func(cred *AssertionCredential) { cred.RawId = []byte("other") },
+			want:  ErrInvalidResponse,
+		},
+		{
+			name: "registration as assertion",
+			after: func(cred *AssertionCredential) {
+				cred.Response.ClientDataJSON = bytes.Replace(cred.Response.ClientDataJSON, []byte(ceremonyGet), []byte(ceremonyCreate), 1)
+			},
+			want: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := testRelyingParty()
+			a := newAuthenticator(t, AlgES256)
+
+			credential := register(t, rp, a)
+			credential.SignCount = 5
+
+			if tt.before != nil {
+				tt.before(a)
+			}
+
+			c := challenge(t)
+			cred := a.get(t, c)
+			if tt.after != nil {
+				tt.after(cred)
+			}
+
+			if _, err := rp.VerifyAssertion(c, cred, credential); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionWithoutCounter(t *testing.T) {
+	rp := testRelyingParty()
+	a := newAuthenticator(t, AlgEdDSA)
+	a.counting = false
+
+	credential := register(t, rp, a)
+
+	// authenticators without a counter always report zero
+	for i := 0; i < 2; i++ {
+		c := challenge(t)
+		signCount, err := rp.VerifyAssertion(c, a.get(t, c), credential)
+		if err != nil || signCount != 0 {
+			t.Fatalf("VerifyAssertion() = %d, %v, want 0, nil", signCount, err)
+		}
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	valid := cborMap("fmt", "none", int64(-2), []byte{1, 2, 3}, int64(7), []any{int64(1), true})
+
+	decoded, rest, err := decodeCBOR(append(valid, 0xff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, []byte{0xff}) {
+		t.Fatalf("rest = %x, want ff", rest)
+	}
+	if m, ok := decoded.(map[any]any); !ok || m["fmt"] != "none" || !bytes.Equal(m[int64(-2)].([]byte), []byte{1, 2, 3}) {
+		t.Fatalf("decoded = %#v", decoded)
+	}
+
+	for i := 0; i < len(valid); i++ {
+		if _, _, err := decodeCBOR(valid[:i]); err == nil {
+			t.Fatalf("truncated to %d bytes: expected an error", i)
+		}
+	}
+
+	nested := bytes.Repeat([]byte{0x81}, maxDepth+2)
+	nested = append(nested, 0x01)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"byte string longer than input", []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		{"text longer than input", []byte{0x7a, 0x7f, 0xff, 0xff, 0xff, 'a'}},
+		{"array longer than input", []byte{0x9b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{"map longer than input", []byte{0xba, 0xff, 0xff, 0xff, 0xff}},
+		{"integer overflow", []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x00, 0xff}},
+		{"nesting too deep", nested},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(tt.data); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseAuthenticatorDataRejects(t *testing.T) {
+	a := newAuthenticator(t, AlgEdDSA)
+
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, a.coseKey()...)
+
+	valid := a.authData(a.flags|flagAttestedData, attested)
+	if _, err := parseAuthenticatorData(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(valid); i++ {
+		if _, err := parseAuthenticatorData(valid[:i]); err == nil {
+			t.Fatalf("truncated to %d bytes: expected an error", i)
+		}
+	}
+
+	if _, err := parseAuthenticatorData(append(valid, 0x00)); err == nil {
+		t.Fatal("trailing data: expected an error")
+	}
+
+	long := a.authData(a.flags|flagAttestedData, binary.BigEndian.AppendUint16(make([]byte, 16), 1024))
+	long = append(long, make([]byte, 1024)...)
+	if _, err := parseAuthenticatorData(long); err == nil {
+		t.Fatal("over-long credential id: expected an error")
+	}
+}
+
+// cborRaw is an already encoded item.
+type cborRaw []byte
+
+// cborMap encodes alternating keys and values as a CBOR map.
+func cborMap(pairs ...any) []byte {
+	out := cborHead(5, uint64(len(pairs)/2))
+	for _, item := range pairs {
+		out = append(out, cborEncode(item)...)
+	}
+	return out
+}
+
+func cborEncode(item any) []byte {
+	switch v := item.(type) {
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case cborRaw:
+		return v
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case []any:
+		out := cborHead(4, uint64(len(v)))
+		for _, e := range v {
+			out = append(out, cborEncode(e)...)
+		}
+		return out
+	}
+	panic("unsupported cbor item")
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+}
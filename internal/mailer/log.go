@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+)
+
+var _ authservice.Mailer = (*LogMailer)(nil)
+
+// LogMailer is a stand-in for local runs, it writes messages to a file
+// or to the service log instead of delivering them.
+type LogMailer struct {
+	from   string
+	path   string
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+func (m *LogMailer) Send(ctx context.Context, mail *dto.Mail) error {
+	log := m.logger.With(slog.String("method", "LogMailer.Send"), slog.String("to", mail.To))
+
+	if m.path == "" {
+		log.Info("mail", slog.String("subject", mail.Subject), slog.String("body", mail.Body))
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Error("error opening mail log", sl.Err(err))
+		return fmt.Errorf("failed writing mail %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(message(m.from, mail), "\r\n\r\n"...)); err != nil {
+		log.Error("error writing mail log", sl.Err(err))
+		return fmt.Errorf("failed writing mail %w", err)
+	}
+
+	log.Debug("mail written", slog.String("path", m.path))
+
+	return nil
+}
+
+func NewLogMailer(cfg *config.Config) *LogMailer {
+	return &LogMailer{
+		from:   cfg.Mail.From,
+		path:   cfg.Mail.LogPath,
+		logger: slog.Default().With(slog.String("struct", "LogMailer")),
+	}
+}
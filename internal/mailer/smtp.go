@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+)
+
+var _ authservice.Mailer = (*SMTPMailer)(nil)
+
+type SMTPMailer struct {
+	addr   string
+	from   string
+	auth   smtp.Auth
+	logger *slog.Logger
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail *dto.Mail) error {
+	log := m.logger.With(slog.String("method", "SMTPMailer.Send"), slog.String("to", mail.To))
+
+	log.Debug("Sending mail", slog.String("subject", mail.Subject))
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{mail.To}, message(m.from, mail)); err != nil {
+		log.Error("error sending mail", sl.Err(err))
+		return fmt.Errorf("failed sending mail %w", err)
+	}
+
+	return nil
+}
+
+func message(from string, mail *dto.Mail) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+func NewSMTPMailer(cfg *config.Config) (*SMTPMailer, error) {
+	if cfg.Mail.SmtpHost == "" {
+		return nil, fmt.Errorf("MAIL_SMTP_HOST is required for the smtp mail driver")
+	}
+
+	var auth smtp.Auth
+	if cfg.Mail.SmtpUser != "" {
+		auth = smtp.PlainAuth("", cfg.Mail.SmtpUser, cfg.Mail.SmtpPass, cfg.Mail.SmtpHost)
+	}
+
+	return &SMTPMailer{
+		addr:   fmt.Sprintf("%s:%d", cfg.Mail.SmtpHost, cfg.Mail.SmtpPort),
+		from:   cfg.Mail.From,
+		auth:   auth,
+		logger: slog.Default().With(slog.String("struct", "SMTPMailer")),
+	}, nil
+}
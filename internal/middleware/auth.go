@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"mzhn/auth/internal/config"
@@ -15,43 +16,90 @@ import (
 
 type RoleFunc func(roles ...entity.Role) echo.MiddlewareFunc
 
+type PermissionFunc func(permissions ...entity.Permission) echo.MiddlewareFunc
+
+// RequireAuth admits users having at least one of the roles, globally or in the organization of the token.
+// With JWT_STATELESS set the roles are taken from the access token claims.
 func RequireAuth(as *authservice.AuthService, cfg *config.Config) RoleFunc {
 	return func(roles ...entity.Role) echo.MiddlewareFunc {
-		return func(next echo.HandlerFunc) echo.HandlerFunc {
-			return func(c echo.Context) error {
-				slog.Debug("require auth check")
-
-				token := c.Get(TOKEN)
-				if token == nil {
-					slog.Error("token not found")
-					return responses.BadRequest(c, errors.New("token not found"))
-				}
+		return requireAuth(as, cfg, &dto.Authenticate{Roles: roles})
+	}
+}
 
-				ctx := c.Request().Context()
+// RequireGlobalAuth admits users having at least one of the roles outside of any organization,
+// guarding platform wide endpoints from organization admins.
+func RequireGlobalAuth(as *authservice.AuthService, cfg *config.Config) RoleFunc {
+	return func(roles ...entity.Role) echo.MiddlewareFunc {
+		return requireAuth(as, cfg, &dto.Authenticate{Roles: roles, Global: true})
+	}
+}
 
-				user, err := as.Authenticate(ctx, &dto.Authenticate{
-					AccessToken: token.(string),
-					Roles:       roles,
-				})
-				if err != nil {
-					slog.Error("failed to authenticate token", sl.Err(err))
+// RequirePermission admits users whose roles grant every one of the permissions.
+// With JWT_STATELESS set the permissions are taken from the access token claims.
+func RequirePermission(as *authservice.AuthService, cfg *config.Config) PermissionFunc {
+	return func(permissions ...entity.Permission) echo.MiddlewareFunc {
+		return requireAuth(as, cfg, &dto.Authenticate{Permissions: permissions})
+	}
+}
 
-					if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrTokenExpired) {
-						return responses.Unauthorized(c)
-					} else if errors.Is(err, authservice.ErrInsufficientPermission) {
-						return responses.Forbidden(c)
-					} else if errors.Is(err, authservice.ErrUserNotFound) {
-						return responses.Unauthorized(c)
-					}
+func requireAuth(as *authservice.AuthService, cfg *config.Config, req *dto.Authenticate) echo.MiddlewareFunc {
+	authenticate := as.Authenticate
+	if cfg.Jwt.Stateless {
+		authenticate = authenticateClaims(as)
+	}
 
-					return responses.Internal(c, err)
-				}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			slog.Debug("require auth check")
 
-				slog.Debug("user authenticated", slog.Any("user", user))
-				c.Set(USER, user)
+			token := c.Get(TOKEN)
+			if token == nil {
+				slog.Error("token not found")
+				return responses.BadRequest(c, errors.New("token not found"))
+			}
 
-				return next(c)
+			ctx := c.Request().Context()
+
+			user, claims, err := authenticate(ctx, &dto.Authenticate{
+				AccessToken: token.(string),
+				Roles:       req.Roles,
+				Permissions: req.Permissions,
+				Global:      req.Global,
+			})
+			if err != nil {
+				slog.Error("failed to authenticate token", sl.Err(err))
+
+				if errors.Is(err, authservice.ErrTokenInvalid) || errors.Is(err, authservice.ErrTokenExpired) || errors.Is(err, authservice.ErrTokenRevoked) {
+					return responses.Unauthorized(c)
+				} else if errors.Is(err, authservice.ErrInsufficientPermission) {
+					return responses.Forbidden(c)
+				} else if errors.Is(err, authservice.ErrMfaEnrollmentRequired) {
+					return c.JSON(403, responses.Payload{"error": err.Error()})
+				} else if errors.Is(err, authservice.ErrUserNotFound) || errors.Is(err, authservice.ErrUserDisabled) {
+					return responses.Unauthorized(c)
+				}
+
+				return responses.Internal(c, err)
 			}
+
+			slog.Debug("user authenticated", slog.Any("user", user))
+			c.Set(USER, user)
+			c.Set(CLAIMS, claims)
+
+			return next(c)
 		}
 	}
 }
+
+// authenticateClaims adapts AuthService.AuthenticateClaims to the handlers reading USER.
+// The user is not looked up, only its Id and Email are set from the token claims.
+func authenticateClaims(as *authservice.AuthService) func(context.Context, *dto.Authenticate) (*entity.User, *entity.UserClaims, error) {
+	return func(ctx context.Context, req *dto.Authenticate) (*entity.User, *entity.UserClaims, error) {
+		claims, err := as.AuthenticateClaims(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &entity.User{Id: claims.Id, Email: claims.Email}, claims, nil
+	}
+}
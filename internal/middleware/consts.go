@@ -1,6 +1,10 @@
 package middleware
 
 const (
-	USER  = "user"
-	TOKEN = "token"
+	// USER holds *entity.User, with JWT_STATELESS set only its Id and Email are filled
+	USER   = "user"
+	TOKEN  = "token"
+	CLAIMS = "claims"
+	// CSRF holds the token RequireCSRF expects in the form
+	CSRF = "csrf"
 )
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	emw "github.com/labstack/echo/v4/middleware"
+)
+
+// CSRFField is the form field carrying the token of the CSRF cookie.
+const CSRFField = "csrf_token"
+
+// RequireCSRF binds a form to a random token kept in a cookie and set into CSRF for rendering.
+// A third-party page can post the form but cannot read the cookie to fill in the token,
+// so the post is rejected, e.g. a login CSRF signing the victim into another account.
+func RequireCSRF(path string) echo.MiddlewareFunc {
+	return emw.CSRFWithConfig(emw.CSRFConfig{
+		TokenLookup:    "form:" + CSRFField,
+		ContextKey:     CSRF,
+		CookieName:     "_csrf",
+		CookiePath:     path,
+		CookieHTTPOnly: true,
+		CookieSameSite: http.SameSiteStrictMode,
+	})
+}
@@ -0,0 +1,104 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+)
+
+// DisableUser keeps the account and its history but signs the user out and blocks any further use.
+func (a *AuthService) DisableUser(ctx context.Context, req *dto.DisableUser) (*entity.User, error) {
+
+	log := a.logger.With(slog.String("method", "DisableUser"), slog.String("userId", req.UserId), slog.String("actorId", req.ActorId))
+
+	if req.UserId == req.ActorId {
+		log.Warn("admin cannot disable themselves")
+		return nil, fmt.Errorf("%w: cannot disable yourself", ErrInvalidRequest)
+	}
+
+	user, err := a.userStorage.SetDisabled(ctx, req.UserId, true)
+	if err != nil {
+		log.Error("disable user error", sl.Err(err))
+		return nil, err
+	}
+
+	if err := a.signOutEverywhere(ctx, user.Id, ""); err != nil {
+		log.Error("sign out error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("user disabled")
+
+	return user, nil
+}
+
+func (a *AuthService) EnableUser(ctx context.Context, userId string) (*entity.User, error) {
+
+	log := a.logger.With(slog.String("method", "EnableUser"), slog.String("userId", userId))
+
+	user, err := a.userStorage.SetDisabled(ctx, userId, false)
+	if err != nil {
+		log.Error("enable user error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("user enabled")
+
+	return user, nil
+}
+
+// DeleteAccount marks the account of the user as deleted, it is purged after the retention window.
+func (a *AuthService) DeleteAccount(ctx context.Context, req *dto.DeleteAccount) error {
+
+	log := a.logger.With(slog.String("method", "DeleteAccount"), slog.String("userId", req.UserId))
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.comparePassword(user.HashedPassword, req.Password); err != nil {
+		log.Warn("password mismatch")
+		return ErrInvalidCredentials
+	}
+
+	if err := a.userStorage.Delete(ctx, user.Id); err != nil {
+		log.Error("delete user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.signOutEverywhere(ctx, user.Id, ""); err != nil {
+		log.Error("sign out error", sl.Err(err))
+		return err
+	}
+
+	log.Info("account deleted")
+
+	return nil
+}
+
+// PurgeDeletedUsers removes accounts deleted longer than the retention window ago.
+func (a *AuthService) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+
+	log := a.logger.With(slog.String("method", "PurgeDeletedUsers"))
+
+	before := time.Now().Add(-time.Duration(a.cfg.Accounts.Retention) * time.Minute)
+
+	n, err := a.userStorage.Purge(ctx, before)
+	if err != nil {
+		log.Error("purge users error", sl.Err(err))
+		return 0, err
+	}
+
+	if n > 0 {
+		log.Info("purged deleted users", slog.Int64("count", n))
+	}
+
+	return n, nil
+}
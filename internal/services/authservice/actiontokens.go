@@ -0,0 +1,70 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/storage"
+)
+
+const (
+	purposeVerifyEmail   = "email-verification"
+	purposeResetPassword = "password-reset"
+	purposeChangeEmail   = "email-change"
+	purposeMfaChallenge  = "mfa-challenge"
+
+	// webauthn ceremonies keep their challenge as a one-time token
+	purposePasskeyRegistration = "passkey-registration"
+	purposePasskeyLogin        = "passkey-login"
+)
+
+func (a *AuthService) actionOptions(purpose string) jwt.Options {
+	return jwt.Options{
+		Issuer:   a.issuer(),
+		Audience: []string{purpose},
+	}
+}
+
+// issueActionToken signs a short-lived token for a single action on behalf of the user,
+// email is the address the action applies to. The token is registered so it can be used only once.
+func (a *AuthService) issueActionToken(ctx context.Context, purpose, userId, email string, ttl time.Duration) (string, error) {
+	token, err := jwt.Sign(&entity.UserClaims{
+		Id:    userId,
+		Email: email,
+	}, ttl, a.refreshKey, a.actionOptions(purpose))
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.tokens.Save(ctx, purpose, token, userId, ttl); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeActionToken verifies the token and marks it as used.
+func (a *AuthService) consumeActionToken(ctx context.Context, purpose, token string) (*entity.UserClaims, error) {
+	claims, err := jwt.Verify(token, a.refreshKey, a.actionOptions(purpose))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+
+	subject, err := a.tokens.Take(ctx, purpose, token)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return nil, fmt.Errorf("%w: already used", ErrTokenInvalid)
+		}
+		return nil, err
+	}
+
+	if subject != claims.Id {
+		return nil, ErrTokenInvalid
+	}
+
+	return claims, nil
+}
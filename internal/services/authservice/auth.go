@@ -3,44 +3,169 @@ package authservice
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"mzhn/auth/internal/config"
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/lib/secret"
+	"mzhn/auth/internal/lib/webauthn"
 )
 
 type UserStorage interface {
 	Find(ctx context.Context, slug string) (*entity.User, error)
+	// FindAny finds disabled users as well, Find reports them as ErrUserDisabled.
+	FindAny(ctx context.Context, slug string) (*entity.User, error)
 	Save(ctx context.Context, user *dto.CreateUser) (*entity.User, error)
+	Update(ctx context.Context, userId string, user *dto.UpdateUser) (*entity.User, error)
+	SetDisabled(ctx context.Context, userId string, disabled bool) (*entity.User, error)
+	Delete(ctx context.Context, userId string) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SetTotp(ctx context.Context, userId string, secret []byte, enabledAt *time.Time) error
 }
 
 type SessionsStorage interface {
-	Check(ctx context.Context, userId, token string) error
-	Save(ctx context.Context, userId, token string) error
-	Delete(ctx context.Context, userId string) error
+	Check(ctx context.Context, sessionId, token string) (*entity.Session, error)
+	Save(ctx context.Context, session *entity.Session, token string) error
+	Rotate(ctx context.Context, session *entity.Session, oldToken, newToken string) error
+	Delete(ctx context.Context, sessionId string) error
+	Find(ctx context.Context, sessionId string) (*entity.Session, error)
+	List(ctx context.Context, userId string) ([]entity.Session, error)
+	// DeleteAll deletes the sessions of the user except one and returns the ids of the deleted ones.
+	DeleteAll(ctx context.Context, userId, exceptSessionId string) ([]string, error)
+}
+
+type RevocationStorage interface {
+	Revoke(ctx context.Context, tokenId string, ttl time.Duration) error
+	RevokeBefore(ctx context.Context, userId string, at time.Time) error
+	RevokeSession(ctx context.Context, sessionId string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, userId, sessionId, tokenId string, issuedAt time.Time) (bool, error)
+}
+
+type ClientStorage interface {
+	Find(ctx context.Context, clientId string) (*entity.Client, error)
+	List(ctx context.Context) ([]entity.Client, error)
+	Save(ctx context.Context, client *entity.Client) (*entity.Client, error)
+	Delete(ctx context.Context, clientId string) error
+}
+
+type AuthCodeStorage interface {
+	Save(ctx context.Context, code string, authCode *entity.AuthCode, ttl time.Duration) error
+	Take(ctx context.Context, code string) (*entity.AuthCode, error)
+}
+
+type InviteStorage interface {
+	Save(ctx context.Context, token string, invite *entity.Invite, ttl time.Duration) error
+	Take(ctx context.Context, token string) (*entity.Invite, error)
+}
+
+// OneTimeTokenStorage keeps tokens of a purpose until they are taken once or expire.
+type OneTimeTokenStorage interface {
+	Save(ctx context.Context, purpose, token, subject string, ttl time.Duration) error
+	Take(ctx context.Context, purpose, token string) (string, error)
+	// Use records the token as spent, it fails with storage.ErrTokenUsed on the second call within ttl.
+	Use(ctx context.Context, purpose, token string, ttl time.Duration) error
+}
+
+type Mailer interface {
+	Send(ctx context.Context, mail *dto.Mail) error
+}
+
+type EventsStorage interface {
+	Publish(ctx context.Context, event *entity.SecurityEvent) error
 }
 
 type RoleStorage interface {
 	Check(ctx context.Context, dto *dto.CheckRoles) (bool, error)
-	ListUser(ctx context.Context, userId string) ([]entity.Role, error)
+	ListUser(ctx context.Context, userId, organizationId string) ([]entity.Role, error)
 	Add(ctx context.Context, dto *dto.AddRoles) error
 	Remove(ctx context.Context, dto *dto.RemoveRoles) error
+	CheckPermissions(ctx context.Context, dto *dto.CheckPermissions) (bool, error)
+	ListUserPermissions(ctx context.Context, userId, organizationId string) ([]entity.Permission, error)
+	Audit(ctx context.Context, userId string) ([]entity.RoleAudit, error)
+
+	Find(ctx context.Context, name entity.Role) (*entity.RoleDefinition, error)
+	List(ctx context.Context) ([]entity.RoleDefinition, error)
+	Save(ctx context.Context, role *dto.CreateRole) (*entity.RoleDefinition, error)
+	Update(ctx context.Context, role *dto.UpdateRole) (*entity.RoleDefinition, error)
+	Delete(ctx context.Context, name entity.Role) error
+}
+
+type OrganizationStorage interface {
+	Find(ctx context.Context, slug string) (*entity.Organization, error)
+	List(ctx context.Context, userId string) ([]entity.Organization, error)
+	Save(ctx context.Context, organization *dto.CreateOrganization) (*entity.Organization, error)
+	Members(ctx context.Context, organizationId string) ([]entity.Member, error)
+	IsMember(ctx context.Context, organizationId, userId string) (bool, error)
+	AddMember(ctx context.Context, organizationId, userId string) error
+	RemoveMember(ctx context.Context, organizationId, userId string) error
+}
+
+type RecoveryCodeStorage interface {
+	Replace(ctx context.Context, userId string, hashes []string) error
+	ListUnused(ctx context.Context, userId string) ([]entity.RecoveryCode, error)
+	Use(ctx context.Context, id int64) (bool, error)
+}
+
+type PasskeyStorage interface {
+	Find(ctx context.Context, id []byte) (*entity.Passkey, error)
+	List(ctx context.Context, userId string) ([]entity.Passkey, error)
+	Save(ctx context.Context, passkey *dto.CreatePasskey) (*entity.Passkey, error)
+	Use(ctx context.Context, id []byte, previous, signCount int64) error
+	Delete(ctx context.Context, userId string, id []byte) error
+}
+
+type PermissionStorage interface {
+	List(ctx context.Context) ([]entity.PermissionDefinition, error)
+	Save(ctx context.Context, permission *dto.CreatePermission) (*entity.PermissionDefinition, error)
+	Delete(ctx context.Context, name entity.Permission) error
 }
 
 type AuthService struct {
 	userStorage    UserStorage
 	roleStorage    RoleStorage
+	permissions    PermissionStorage
+	organizations  OrganizationStorage
 	sessionStorage SessionsStorage
+	eventsStorage  EventsStorage
+	revocations    RevocationStorage
+	authCodes      AuthCodeStorage
+	invites        InviteStorage
+	tokens         OneTimeTokenStorage
+	recoveryCodes  RecoveryCodeStorage
+	passkeys       PasskeyStorage
+	mailer         Mailer
+	clientStorage  ClientStorage
+	keyring        *jwt.Keyring
+	refreshKey     *jwt.Key
+	secrets        *secret.Box
+	relyingParty   *webauthn.RelyingParty
 	cfg            *config.Config
 	logger         *slog.Logger
 }
 
-func New(userStorage UserStorage, roleStorage RoleStorage, sessionStorage SessionsStorage, cfg *config.Config) *AuthService {
+func New(userStorage UserStorage, roleStorage RoleStorage, permissions PermissionStorage, organizations OrganizationStorage, sessionStorage SessionsStorage, eventsStorage EventsStorage, revocations RevocationStorage, authCodes AuthCodeStorage, invites InviteStorage, tokens OneTimeTokenStorage, recoveryCodes RecoveryCodeStorage, passkeys PasskeyStorage, mailer Mailer, clientStorage ClientStorage, keyring *jwt.Keyring, secrets *secret.Box, cfg *config.Config) *AuthService {
 	return &AuthService{
 		cfg:            cfg,
 		userStorage:    userStorage,
 		roleStorage:    roleStorage,
+		permissions:    permissions,
+		organizations:  organizations,
 		sessionStorage: sessionStorage,
+		eventsStorage:  eventsStorage,
+		revocations:    revocations,
+		authCodes:      authCodes,
+		invites:        invites,
+		tokens:         tokens,
+		recoveryCodes:  recoveryCodes,
+		passkeys:       passkeys,
+		mailer:         mailer,
+		clientStorage:  clientStorage,
+		keyring:        keyring,
+		secrets:        secrets,
+		relyingParty:   newRelyingParty(cfg),
+		refreshKey:     jwt.NewHMACKey("", []byte(cfg.Jwt.RefreshSecret)),
 		logger:         slog.Default().With(slog.String("struct", "AuthService")),
 	}
 }
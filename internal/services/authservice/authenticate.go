@@ -2,46 +2,137 @@ package authservice
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
-	"mzhn/auth/internal/lib/jwt"
 	"mzhn/auth/internal/lib/logger/sl"
+
+	"github.com/samber/lo"
 )
 
-func (a *AuthService) Authenticate(ctx context.Context, req *dto.Authenticate) (*entity.User, error) {
+func (a *AuthService) Authenticate(ctx context.Context, req *dto.Authenticate) (*entity.User, *entity.UserClaims, error) {
 
 	log := a.logger.With("method", "Authenticate")
 	log.Debug("authenticating", slog.Any("req", req))
 
-	claims, err := jwt.Verify(req.AccessToken, a.cfg.Jwt.AccessSecret)
+	claims, err := a.verifyAccessToken(ctx, req.AccessToken)
 	if err != nil {
-		log.Warn("invalid token", sl.Err(err))
-		return nil, ErrTokenInvalid
+		log.Warn("access token rejected", sl.Err(err))
+		return nil, nil, err
 	}
 
-	log.Debug("claims", slog.Any("claims", claims))
+	if claims.ClientId != "" {
+		log.Warn("client token cannot authenticate a user", slog.String("clientId", claims.ClientId))
+		return nil, nil, ErrTokenInvalid
+	}
 
 	user, err := a.userStorage.Find(ctx, claims.Id)
 	if err != nil {
 		log.Error("user not found", sl.Err(err))
-		return nil, ErrUserNotFound
+		if errors.Is(err, ErrUserDisabled) {
+			return nil, nil, ErrUserDisabled
+		}
+		return nil, nil, ErrUserNotFound
+	}
+
+	organizationId := claims.OrganizationId
+	if req.Global {
+		organizationId = ""
 	}
 
 	ok, err := a.roleStorage.Check(ctx, &dto.CheckRoles{
-		UserId: user.Id,
-		Roles:  req.Roles,
+		UserId:         user.Id,
+		OrganizationId: organizationId,
+		Roles:          req.Roles,
 	})
 	if err != nil {
 		log.Error("check roles error", sl.Err(err))
-		return nil, err
+		return nil, nil, err
 	}
 
 	log.Debug("authenticate result", slog.Any("ok", ok), slog.Any("user", user))
 
 	if !ok {
+		return nil, nil, ErrInsufficientPermission
+	}
+
+	ok, err = a.roleStorage.CheckPermissions(ctx, &dto.CheckPermissions{
+		UserId:         user.Id,
+		OrganizationId: organizationId,
+		Permissions:    req.Permissions,
+	})
+	if err != nil {
+		log.Error("check permissions error", sl.Err(err))
+		return nil, nil, err
+	}
+
+	if !ok {
+		log.Debug("missing permissions", slog.Any("permissions", req.Permissions))
+		return nil, nil, ErrInsufficientPermission
+	}
+
+	// admins may not use admin routes until they enroll a second factor
+	if user.TotpEnabledAt == nil && a.mfaEnforced(req.Roles) {
+		admin, err := a.roleStorage.Check(ctx, &dto.CheckRoles{
+			UserId:         user.Id,
+			OrganizationId: organizationId,
+			Roles:          []entity.Role{entity.RoleAdmin},
+		})
+		if err != nil {
+			log.Error("check roles error", sl.Err(err))
+			return nil, nil, err
+		}
+
+		if admin {
+			log.Warn("admin without second factor", slog.String("userId", user.Id))
+			return nil, nil, ErrMfaEnrollmentRequired
+		}
+	}
+
+	return user, claims, nil
+}
+
+// AuthenticateClaims authorizes by the roles and permissions signed into the access token
+// without looking up the user, only the revocation list is consulted. Role changes take
+// effect once the token is refreshed, revoked roles force that through the revocation watermark.
+// Only the claims are returned, the token carries no more of the user than its id and email.
+func (a *AuthService) AuthenticateClaims(ctx context.Context, req *dto.Authenticate) (*entity.UserClaims, error) {
+
+	log := a.logger.With("method", "AuthenticateClaims")
+
+	claims, err := a.verifyAccessToken(ctx, req.AccessToken)
+	if err != nil {
+		log.Warn("access token rejected", sl.Err(err))
+		return nil, err
+	}
+
+	if claims.ClientId != "" {
+		log.Warn("client token cannot authenticate a user", slog.String("clientId", claims.ClientId))
+		return nil, ErrTokenInvalid
+	}
+
+	// claims of an organization token mix global and organization roles
+	if req.Global && claims.OrganizationId != "" && len(req.Roles)+len(req.Permissions) > 0 {
+		log.Debug("organization token cannot prove global roles", slog.String("organizationId", claims.OrganizationId))
 		return nil, ErrInsufficientPermission
 	}
 
-	return user, nil
+	if len(req.Roles) > 0 && !lo.Some(claims.Roles, req.Roles) {
+		log.Debug("missing roles", slog.Any("roles", req.Roles))
+		return nil, ErrInsufficientPermission
+	}
+
+	if !lo.Every(claims.Permissions, req.Permissions) {
+		log.Debug("missing permissions", slog.Any("permissions", req.Permissions))
+		return nil, ErrInsufficientPermission
+	}
+
+	// the claim is set for sessions started after the second factor was enrolled
+	if !claims.Mfa && a.mfaEnforced(req.Roles) && a.mfaEnforced(claims.Roles) {
+		log.Warn("admin without second factor", slog.String("userId", claims.Id))
+		return nil, ErrMfaEnrollmentRequired
+	}
+
+	return claims, nil
 }
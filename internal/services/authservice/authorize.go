@@ -0,0 +1,124 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+
+	"github.com/samber/lo"
+)
+
+const (
+	ScopeOpenId  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+
+	codeChallengeS256 = "S256"
+)
+
+// CheckAuthorize validates an authorization request before the user is asked to sign in.
+// ErrInvalidClient and ErrInvalidRedirectUri must not be reported back to the redirect uri.
+func (a *AuthService) CheckAuthorize(ctx context.Context, req *dto.AuthorizeParams) error {
+
+	log := a.logger.With(slog.String("method", "CheckAuthorize"), slog.String("clientId", req.ClientId))
+
+	client, err := a.client(ctx, req.ClientId)
+	if err != nil {
+		log.Warn("unknown client", sl.Err(err))
+		return err
+	}
+
+	if !lo.Contains(client.RedirectUris, req.RedirectUri) {
+		log.Warn("redirect uri not registered", slog.String("redirectUri", req.RedirectUri))
+		return ErrInvalidRedirectUri
+	}
+
+	if req.ResponseType != "code" {
+		return ErrUnsupportedResponseType
+	}
+
+	if !lo.Contains(client.GrantTypes, GrantAuthorizationCode) {
+		return ErrUnauthorizedClient
+	}
+
+	scope := strings.Fields(req.Scope)
+	if !lo.Contains(scope, ScopeOpenId) || !lo.Every(client.Scopes, scope) {
+		return ErrInvalidScope
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != codeChallengeS256 {
+		return fmt.Errorf("%w: PKCE with S256 is required", ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// Authorize signs the user in and issues a single-use authorization code for the client.
+func (a *AuthService) Authorize(ctx context.Context, req *dto.Authorize) (string, error) {
+
+	log := a.logger.With(slog.String("method", "Authorize"), slog.String("clientId", req.ClientId))
+
+	if err := a.CheckAuthorize(ctx, &req.AuthorizeParams); err != nil {
+		return "", err
+	}
+
+	user, err := a.checkCredentials(ctx, req.Email, req.Password)
+	if err != nil {
+		log.Warn("check credentials error", sl.Err(err))
+		return "", err
+	}
+
+	if user.TotpEnabledAt != nil {
+		if req.Otp == "" {
+			return "", ErrMfaRequired
+		}
+
+		if err := a.checkSecondFactor(ctx, user, req.Otp); err != nil {
+			log.Warn("second factor rejected", sl.Err(err))
+			return "", err
+		}
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		log.Error("generate code error", sl.Err(err))
+		return "", err
+	}
+
+	if err := a.authCodes.Save(ctx, code, &entity.AuthCode{
+		ClientId:            req.ClientId,
+		RedirectUri:         req.RedirectUri,
+		UserId:              user.Id,
+		Scope:               strings.Fields(req.Scope),
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		AuthTime:            time.Now(),
+	}, time.Duration(a.cfg.Oidc.CodeTTL)*time.Second); err != nil {
+		log.Error("save code error", sl.Err(err))
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (a *AuthService) issuer() string {
+	if a.cfg.Oidc.Issuer != "" {
+		return strings.TrimSuffix(a.cfg.Oidc.Issuer, "/")
+	}
+
+	return fmt.Sprintf("http://localhost:%d", a.cfg.App.Port)
+}
+
+func (a *AuthService) Discovery() *dto.Discovery {
+	return &dto.Discovery{
+		Issuer:      a.issuer(),
+		SigningAlgs: a.keyring.Algs(),
+	}
+}
@@ -0,0 +1,193 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+var (
+	supportedGrantTypes = []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials}
+	supportedScopes     = []string{ScopeOpenId, ScopeProfile, ScopeEmail}
+)
+
+// CreateClient registers an OAuth client. The returned secret is shown only once,
+// public clients get an empty secret.
+func (a *AuthService) CreateClient(ctx context.Context, req *dto.CreateClient) (*entity.Client, string, error) {
+
+	log := a.logger.With(slog.String("method", "CreateClient"), slog.String("name", req.Name))
+
+	if req.Name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	if len(req.GrantTypes) == 0 || !lo.Every(supportedGrantTypes, req.GrantTypes) {
+		return nil, "", fmt.Errorf("%w: grant types must be some of %v", ErrInvalidRequest, supportedGrantTypes)
+	}
+
+	if lo.Contains(req.GrantTypes, GrantAuthorizationCode) && len(req.RedirectUris) == 0 {
+		return nil, "", fmt.Errorf("%w: %s requires redirect uris", ErrInvalidRequest, GrantAuthorizationCode)
+	}
+
+	if lo.Contains(req.GrantTypes, GrantClientCredentials) && !req.Confidential {
+		return nil, "", fmt.Errorf("%w: %s requires a confidential client", ErrInvalidRequest, GrantClientCredentials)
+	}
+
+	for _, uri := range req.RedirectUris {
+		if err := validateRedirectUri(uri); err != nil {
+			return nil, "", fmt.Errorf("%w: redirect uri %q %w", ErrInvalidRequest, uri, err)
+		}
+	}
+
+	if unknown := lo.Without(req.Scopes, supportedScopes...); len(unknown) > 0 {
+		return nil, "", fmt.Errorf("%w: unknown scopes %v, must be some of %v", ErrInvalidRequest, unknown, supportedScopes)
+	}
+
+	client := &entity.Client{
+		Id:           uuid.NewString(),
+		Name:         req.Name,
+		RedirectUris: req.RedirectUris,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+	}
+
+	var secret string
+	if req.Confidential {
+		var err error
+		secret, err = randomToken(32)
+		if err != nil {
+			log.Error("generate secret error", sl.Err(err))
+			return nil, "", err
+		}
+
+		hashed, err := a.hash(secret)
+		if err != nil {
+			log.Error("hash secret error", sl.Err(err))
+			return nil, "", err
+		}
+		client.HashedSecret = &hashed
+	}
+
+	client, err := a.clientStorage.Save(ctx, client)
+	if err != nil {
+		log.Error("save client error", sl.Err(err))
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+// validateRedirectUri admits absolute uris without a fragment, served over https
+// unless they point to the loopback interface of a native app.
+func validateRedirectUri(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return errors.New("must be absolute")
+	}
+
+	if u.Fragment != "" || strings.Contains(uri, "#") {
+		return errors.New("must not contain a fragment")
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if host := u.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+			return nil
+		}
+	}
+
+	return errors.New("must use https")
+}
+
+func (a *AuthService) Clients(ctx context.Context) ([]entity.Client, error) {
+
+	log := a.logger.With(slog.String("method", "Clients"))
+
+	clients, err := a.clientStorage.List(ctx)
+	if err != nil {
+		log.Error("list clients error", sl.Err(err))
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (a *AuthService) DeleteClient(ctx context.Context, clientId string) error {
+
+	log := a.logger.With(slog.String("method", "DeleteClient"), slog.String("clientId", clientId))
+
+	if err := a.clientStorage.Delete(ctx, clientId); err != nil {
+		log.Error("delete client error", sl.Err(err))
+		if errors.Is(err, storage.ErrClientNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (a *AuthService) client(ctx context.Context, clientId string) (*entity.Client, error) {
+	client, err := a.clientStorage.Find(ctx, clientId)
+	if err != nil {
+		if errors.Is(err, storage.ErrClientNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// authenticateClient checks the secret of confidential clients.
+// Public clients must not present a secret.
+func (a *AuthService) authenticateClient(ctx context.Context, clientId, secret string) (*entity.Client, error) {
+	client, err := a.client(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Confidential() {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	if err := a.comparePassword(*client.HashedSecret, secret); err != nil {
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+func (a *AuthService) authenticateConfidentialClient(ctx context.Context, clientId, secret string) (*entity.Client, error) {
+	client, err := a.authenticateClient(ctx, clientId, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Confidential() {
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
@@ -5,7 +5,41 @@ import "errors"
 var (
 	ErrEmailTaken             = errors.New("email taken")
 	ErrUserNotFound           = errors.New("user not found")
+	ErrUserDisabled           = errors.New("user disabled")
 	ErrInsufficientPermission = errors.New("insufficient permission")
 	ErrTokenExpired           = errors.New("token expired")
 	ErrTokenInvalid           = errors.New("token invalid")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrTokenReused            = errors.New("token reused")
+	ErrTokenRevoked           = errors.New("token revoked")
+	ErrAudienceInvalid        = errors.New("audience invalid")
+	ErrInvalidCredentials     = errors.New("invalid credentials")
+	ErrEmailNotVerified       = errors.New("email not verified")
+	ErrMfaRequired            = errors.New("second factor required")
+	ErrMfaCodeInvalid         = errors.New("authentication code invalid")
+	ErrMfaNotEnrolled         = errors.New("second factor not enrolled")
+	ErrMfaAlreadyEnabled      = errors.New("second factor already enabled")
+	ErrMfaEnrollmentRequired  = errors.New("second factor enrollment required")
+	ErrMfaUnavailable         = errors.New("second factor not configured")
+	ErrPasskeyInvalid         = errors.New("passkey invalid")
+	ErrPasskeyNotFound        = errors.New("passkey not found")
+	ErrPasskeyExists          = errors.New("passkey already registered")
+	ErrOrganizationNotFound   = errors.New("organization not found")
+	ErrOrganizationExists     = errors.New("organization already exists")
+	ErrNotMember              = errors.New("not a member of the organization")
+	ErrInviteInvalid          = errors.New("invite invalid")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleExists             = errors.New("role already exists")
+	ErrPermissionNotFound     = errors.New("permission not found")
+	ErrPermissionExists       = errors.New("permission already exists")
+
+	ErrInvalidRequest          = errors.New("invalid request")
+	ErrInvalidClient           = errors.New("invalid client")
+	ErrInvalidRedirectUri      = errors.New("invalid redirect uri")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrInvalidGrant            = errors.New("invalid grant")
+	ErrUnauthorizedClient      = errors.New("unauthorized client")
+	ErrClientNotFound          = errors.New("client not found")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant type")
 )
@@ -0,0 +1,91 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/lib/logger/sl"
+)
+
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
+// Introspect returns the claims of an active access token issued to a user or a client.
+func (a *AuthService) Introspect(ctx context.Context, token string) (*entity.UserClaims, error) {
+
+	log := a.logger.With(slog.String("method", "Introspect"))
+
+	claims, err := a.verifyAccessToken(ctx, token)
+	if err != nil {
+		log.Debug("inactive token", sl.Err(err))
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// IntrospectToken implements RFC 7662 for access and refresh tokens on behalf of a confidential client.
+func (a *AuthService) IntrospectToken(ctx context.Context, req *dto.IntrospectToken) (*dto.Introspection, error) {
+
+	log := a.logger.With(slog.String("method", "IntrospectToken"), slog.String("clientId", req.ClientId))
+
+	if _, err := a.authenticateConfidentialClient(ctx, req.ClientId, req.ClientSecret); err != nil {
+		log.Warn("client authentication failed", sl.Err(err))
+		return nil, err
+	}
+
+	for _, tokenType := range tokenTypes(req.TokenTypeHint) {
+		var (
+			claims *entity.UserClaims
+			err    error
+		)
+
+		switch tokenType {
+		case TokenTypeAccess:
+			claims, err = a.verifyAccessToken(ctx, req.Token)
+		case TokenTypeRefresh:
+			claims, err = a.verifyRefreshToken(ctx, req.Token)
+		}
+		if err != nil {
+			log.Debug("not an active token", slog.String("type", tokenType), sl.Err(err))
+			continue
+		}
+
+		return &dto.Introspection{
+			Active:    true,
+			TokenType: tokenType,
+			Issuer:    a.issuer(),
+			Claims:    claims,
+		}, nil
+	}
+
+	return &dto.Introspection{Active: false}, nil
+}
+
+// verifyRefreshToken accepts only the current refresh token of a live session.
+func (a *AuthService) verifyRefreshToken(ctx context.Context, token string) (*entity.UserClaims, error) {
+	claims, err := jwt.Verify(token, a.refreshKey, a.verifyOptions())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+
+	if _, err := a.sessionStorage.Check(ctx, claims.SessionId, token); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+
+	return claims, nil
+}
+
+// tokenTypes returns the token types to try, the hinted one first.
+func tokenTypes(hint string) []string {
+	if hint == TokenTypeRefresh {
+		return []string{TokenTypeRefresh, TokenTypeAccess}
+	}
+	return []string{TokenTypeAccess, TokenTypeRefresh}
+}
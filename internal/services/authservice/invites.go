@@ -0,0 +1,76 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+)
+
+// CreateInvite issues a single-use registration token carrying pre-approved roles.
+// The token is returned only once.
+func (a *AuthService) CreateInvite(ctx context.Context, req *dto.CreateInvite) (string, *entity.Invite, error) {
+
+	log := a.logger.With(slog.String("method", "CreateInvite"), slog.String("createdBy", req.CreatedBy))
+
+	if err := a.checkRolesExist(ctx, req.Roles); err != nil {
+		log.Warn("unknown role", sl.Err(err))
+		return "", nil, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		log.Error("generate invite token error", sl.Err(err))
+		return "", nil, err
+	}
+
+	ttl := time.Duration(a.cfg.Registration.InviteTTL) * time.Minute
+	invite := &entity.Invite{
+		Email:     req.Email,
+		Roles:     req.Roles,
+		CreatedBy: req.CreatedBy,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := a.invites.Save(ctx, token, invite, ttl); err != nil {
+		log.Error("save invite error", sl.Err(err))
+		return "", nil, err
+	}
+
+	log.Info("invite created", slog.Any("roles", invite.Roles))
+
+	return token, invite, nil
+}
+
+// redeemInvite consumes the invite, it cannot be used again even if registration fails later.
+func (a *AuthService) redeemInvite(ctx context.Context, token, email string) (*entity.Invite, error) {
+	invite, err := a.invites.Take(ctx, token)
+	if err != nil {
+		if errors.Is(err, storage.ErrInviteNotFound) {
+			return nil, ErrInviteInvalid
+		}
+		return nil, err
+	}
+
+	if invite.Email != nil && !strings.EqualFold(*invite.Email, email) {
+		return nil, fmt.Errorf("%w: issued for another email", ErrInviteInvalid)
+	}
+
+	return invite, nil
+}
+
+func (a *AuthService) checkRolesExist(ctx context.Context, roles []entity.Role) error {
+	for _, role := range roles {
+		if _, err := a.roleStorage.Find(ctx, role); err != nil {
+			return roleError(err)
+		}
+	}
+	return nil
+}
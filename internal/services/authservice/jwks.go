@@ -0,0 +1,8 @@
+package authservice
+
+import "mzhn/auth/internal/lib/jwt"
+
+// JWKS returns the public keys other services use to verify access tokens.
+func (a *AuthService) JWKS() *jwt.JWKS {
+	return a.keyring.JWKS()
+}
@@ -1,11 +1,18 @@
 package authservice
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/jwt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -25,13 +32,127 @@ func (a *AuthService) comparePassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func (a *AuthService) generateJwtPair(claims *entity.UserClaims) (*dto.Tokens, error) {
-	accessToken, err := jwt.Sign(claims, time.Duration(a.cfg.Jwt.AccessTTL)*time.Minute, []byte(a.cfg.Jwt.AccessSecret))
+// checkCredentials reports a disabled account only to whoever knows its password.
+func (a *AuthService) checkCredentials(ctx context.Context, email, password string) (*entity.User, error) {
+	user, err := a.userStorage.FindAny(ctx, email)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
 		return nil, err
 	}
 
-	refreshToken, err := jwt.Sign(claims, time.Duration(a.cfg.Jwt.RefreshTTL)*time.Minute, []byte(a.cfg.Jwt.RefreshSecret))
+	if err := a.comparePassword(user.HashedPassword, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.DisabledAt != nil {
+		return nil, ErrUserDisabled
+	}
+
+	if a.cfg.Registration.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	return user, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// audiences returns the audiences access tokens may be issued for, the first one is the default.
+func (a *AuthService) audiences() []string {
+	if len(a.cfg.Jwt.Audience) == 0 {
+		return []string{a.cfg.App.Name}
+	}
+	return a.cfg.Jwt.Audience
+}
+
+func (a *AuthService) audience(requested string) (string, error) {
+	if requested == "" {
+		return a.audiences()[0], nil
+	}
+
+	if !lo.Contains(a.audiences(), requested) {
+		return "", ErrAudienceInvalid
+	}
+
+	return requested, nil
+}
+
+func (a *AuthService) verifyOptions() jwt.Options {
+	return jwt.Options{
+		Issuer:   a.issuer(),
+		Audience: a.audiences(),
+	}
+}
+
+func (a *AuthService) accessTTL() time.Duration {
+	return time.Duration(a.cfg.Jwt.AccessTTL) * time.Minute
+}
+
+// verifyAccessToken checks the signature, issuer, audience and revocation of an access token.
+func (a *AuthService) verifyAccessToken(ctx context.Context, token string) (*entity.UserClaims, error) {
+	claims, err := jwt.Verify(token, a.keyring, a.verifyOptions())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+
+	revoked, err := a.revocations.IsRevoked(ctx, claims.Id, claims.SessionId, claims.TokenId, claims.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// generateJwtPair embeds the current roles and permissions of the user into the access token,
+// the refresh token carries only the identity.
+func (a *AuthService) generateJwtPair(ctx context.Context, claims *entity.UserClaims) (*dto.Tokens, error) {
+	opts := jwt.Options{
+		Issuer:   a.issuer(),
+		Audience: claims.Audience,
+	}
+
+	accessKey, err := a.keyring.Active()
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := a.EffectiveRoles(ctx, claims.Id, claims.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := a.roleStorage.ListUserPermissions(ctx, claims.Id, claims.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	access := *claims
+	access.Roles = roles
+	access.Permissions = permissions
+
+	accessToken, err := jwt.Sign(&access, a.accessTTL(), accessKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := *claims
+	refresh.Roles = nil
+	refresh.Permissions = nil
+
+	refreshToken, err := jwt.Sign(&refresh, time.Duration(a.cfg.Jwt.RefreshTTL)*time.Minute, a.refreshKey, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -41,3 +162,45 @@ func (a *AuthService) generateJwtPair(claims *entity.UserClaims) (*dto.Tokens, e
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+func (a *AuthService) startSession(ctx context.Context, user *entity.User, device *dto.Device, audience string) (*dto.Tokens, error) {
+	return a.startClientSession(ctx, user, device, audience, "", nil)
+}
+
+// startClientSession starts a session on behalf of an OAuth client, only that client may refresh it.
+// The granted scope is carried by the tokens and kept on refresh.
+func (a *AuthService) startClientSession(ctx context.Context, user *entity.User, device *dto.Device, audience, clientId string, scope []string) (*dto.Tokens, error) {
+	aud, err := a.audience(audience)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &entity.Session{
+		Id:         uuid.NewString(),
+		UserId:     user.Id,
+		ClientId:   clientId,
+		UserAgent:  device.UserAgent,
+		Ip:         device.Ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	tokens, err := a.generateJwtPair(ctx, &entity.UserClaims{
+		Id:        user.Id,
+		Email:     user.Email,
+		SessionId: session.Id,
+		Scope:     scope,
+		Audience:  []string{aud},
+		Mfa:       user.TotpEnabledAt != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.sessionStorage.Save(ctx, session, tokens.RefreshToken); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
@@ -4,37 +4,46 @@ import (
 	"context"
 	"log/slog"
 	"mzhn/auth/internal/dto"
-	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
+	"time"
 )
 
-func (a *AuthService) Login(ctx context.Context, req *dto.Login) (*dto.Tokens, error) {
+// Login checks the credentials and starts a session.
+// Users with a second factor get a challenge instead of tokens, see VerifyMfa.
+func (a *AuthService) Login(ctx context.Context, req *dto.Login) (*dto.Tokens, *dto.MfaChallenge, error) {
 
 	log := a.logger.With("method", "Login")
 
-	log.Debug("logging in", slog.Any("req", req))
+	log.Debug("logging in", slog.String("email", req.Email))
 
-	user, err := a.userStorage.Find(ctx, req.Email)
+	user, err := a.checkCredentials(ctx, req.Email, req.Password)
 	if err != nil {
-		log.Error("user not found", sl.Err(err))
-		return nil, err
+		log.Error("check credentials error", sl.Err(err))
+		return nil, nil, err
 	}
 
-	if err := a.comparePassword(user.HashedPassword, req.Password); err != nil {
-		log.Error("password not match", sl.Err(err))
-		return nil, err
-	}
+	if user.TotpEnabledAt != nil {
+		ttl := time.Duration(a.cfg.Mfa.ChallengeTTL) * time.Minute
 
-	tokens, err := a.generateJwtPair(&entity.UserClaims{Id: user.Id, Email: user.Email})
-	if err != nil {
-		log.Error("generate jwt pair error", sl.Err(err))
-		return nil, err
+		token, err := a.issueActionToken(ctx, purposeMfaChallenge, user.Id, user.Email, ttl)
+		if err != nil {
+			log.Error("issue mfa challenge error", sl.Err(err))
+			return nil, nil, err
+		}
+
+		log.Debug("second factor required", slog.String("userId", user.Id))
+
+		return nil, &dto.MfaChallenge{
+			Token:     token,
+			ExpiresAt: time.Now().Add(ttl),
+		}, nil
 	}
 
-	if a.sessionStorage.Save(ctx, user.Id, tokens.RefreshToken); err != nil {
-		log.Error("save session error", sl.Err(err))
-		return nil, err
+	tokens, err := a.startSession(ctx, user, &req.Device, req.Audience)
+	if err != nil {
+		log.Error("start session error", sl.Err(err))
+		return nil, nil, err
 	}
 
-	return tokens, nil
+	return tokens, nil, nil
 }
@@ -2,22 +2,34 @@ package authservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+	"time"
 )
 
-func (a *AuthService) Logout(ctx context.Context, userId string) error {
+func (a *AuthService) Logout(ctx context.Context, claims *entity.UserClaims) error {
 
-	log := a.logger.With(slog.String("method", "Logout"))
+	log := a.logger.With(slog.String("method", "Logout"), slog.String("sessionId", claims.SessionId))
 
-	if _, err := a.userStorage.Find(ctx, userId); err != nil {
+	if _, err := a.userStorage.Find(ctx, claims.Id); err != nil {
 		log.Warn("user not found to logout", sl.Err(err))
 		return fmt.Errorf("user not exists %w", err)
 	}
 
-	if err := a.sessionStorage.Delete(ctx, userId); err != nil {
+	if err := a.revocations.Revoke(ctx, claims.TokenId, time.Until(claims.ExpiresAt)); err != nil {
+		log.Warn("failed to revoke access token", sl.Err(err))
+		return fmt.Errorf("failed to revoke access token %w", err)
+	}
+
+	if err := a.endSession(ctx, claims.SessionId); err != nil {
 		log.Warn("failed to delete session", sl.Err(err))
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
 		return fmt.Errorf("failed to delete session %w", err)
 	}
 
@@ -0,0 +1,53 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+)
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	ctx := context.Background()
+	a := newRefreshService(t)
+
+	user := &entity.User{Id: "user-1", Email: "user-1@example.com"}
+	a.userStorage = &memoryUsers{users: map[string]*entity.User{user.Id: user}}
+
+	current, err := a.startSession(ctx, user, &dto.Device{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := a.startSession(ctx, user, &dto.Device{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := a.verifyAccessToken(ctx, current.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Logout(ctx, claims); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	if revoked := a.revocations.(*memoryRevocations).tokens; len(revoked) != 1 || revoked[0] != claims.TokenId {
+		t.Fatalf("revoked tokens = %v, want [%s]", revoked, claims.TokenId)
+	}
+
+	if _, err := a.verifyAccessToken(ctx, current.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("access token after logout: err = %v, want %v", err, ErrTokenRevoked)
+	}
+
+	if _, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: current.RefreshToken}); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("refresh after logout: err = %v, want %v", err, ErrTokenInvalid)
+	}
+
+	if _, err := a.verifyAccessToken(ctx, other.AccessToken); err != nil {
+		t.Fatalf("access token of another session: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/lib/totp"
+	"mzhn/auth/internal/storage"
+
+	"github.com/samber/lo"
+)
+
+// totpSkew is the number of time steps accepted on each side of the current one.
+const totpSkew = 1
+
+// VerifyMfa completes a login started by Login with the code of the second factor.
+// The challenge is single-use, a wrong code requires logging in again.
+func (a *AuthService) VerifyMfa(ctx context.Context, req *dto.VerifyMfa) (*dto.Tokens, error) {
+
+	log := a.logger.With(slog.String("method", "VerifyMfa"))
+
+	claims, err := a.consumeActionToken(ctx, purposeMfaChallenge, req.Token)
+	if err != nil {
+		log.Warn("mfa challenge rejected", sl.Err(err))
+		return nil, err
+	}
+
+	log = log.With(slog.String("userId", claims.Id))
+
+	user, err := a.userStorage.Find(ctx, claims.Id)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if err := a.checkSecondFactor(ctx, user, req.Code); err != nil {
+		log.Warn("second factor rejected", sl.Err(err))
+		return nil, err
+	}
+
+	tokens, err := a.startSession(ctx, user, &req.Device, req.Audience)
+	if err != nil {
+		log.Error("start session error", sl.Err(err))
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// EnrollTotp generates a new secret for the user, it is not used until ConfirmTotp.
+func (a *AuthService) EnrollTotp(ctx context.Context, userId string) (*dto.TotpEnrollment, error) {
+
+	log := a.logger.With(slog.String("method", "EnrollTotp"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	if a.secrets == nil {
+		return nil, ErrMfaUnavailable
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("generate secret error", sl.Err(err))
+		return nil, err
+	}
+
+	sealed, err := a.secrets.Seal(secret)
+	if err != nil {
+		log.Error("encrypt secret error", sl.Err(err))
+		return nil, err
+	}
+
+	if err := a.userStorage.SetTotp(ctx, user.Id, sealed, nil); err != nil {
+		log.Error("save secret error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("totp enrollment started")
+
+	return &dto.TotpEnrollment{
+		Secret: totp.Encode(secret),
+		Uri:    totp.URI(a.mfaIssuer(), user.Email, secret),
+	}, nil
+}
+
+// ConfirmTotp enables the enrolled secret once the user proves the authenticator app produces valid codes.
+// It returns the initial recovery codes, they are not shown again.
+func (a *AuthService) ConfirmTotp(ctx context.Context, userId, code string) ([]string, error) {
+
+	log := a.logger.With(slog.String("method", "ConfirmTotp"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	if err := a.checkTotp(ctx, user, code); err != nil {
+		log.Warn("confirmation code rejected", sl.Err(err))
+		return nil, err
+	}
+
+	codes, err := a.issueRecoveryCodes(ctx, user.Id)
+	if err != nil {
+		log.Error("issue recovery codes error", sl.Err(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := a.userStorage.SetTotp(ctx, user.Id, user.TotpSecret, &now); err != nil {
+		log.Error("enable totp error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("totp enabled")
+
+	return codes, nil
+}
+
+// DisableTotp removes the second factor and its recovery codes.
+// A current code is required, a recovery code will do for a lost device.
+func (a *AuthService) DisableTotp(ctx context.Context, userId, code string) error {
+
+	log := a.logger.With(slog.String("method", "DisableTotp"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if user.TotpEnabledAt == nil {
+		return ErrMfaNotEnrolled
+	}
+
+	if err := a.checkSecondFactor(ctx, user, code); err != nil {
+		log.Warn("code rejected", sl.Err(err))
+		return err
+	}
+
+	if err := a.userStorage.SetTotp(ctx, user.Id, nil, nil); err != nil {
+		log.Error("disable totp error", sl.Err(err))
+		return err
+	}
+
+	if err := a.recoveryCodes.Replace(ctx, user.Id, nil); err != nil {
+		log.Error("delete recovery codes error", sl.Err(err))
+		return err
+	}
+
+	// access tokens still claim the second factor, make the user refresh them
+	if err := a.revocations.RevokeBefore(ctx, user.Id, time.Now()); err != nil {
+		log.Error("revoke access tokens error", sl.Err(err))
+		return err
+	}
+
+	log.Info("totp disabled")
+
+	return nil
+}
+
+// checkTotp validates the code against the secret of the user, every code is accepted only once.
+func (a *AuthService) checkTotp(ctx context.Context, user *entity.User, code string) error {
+	if user.TotpSecret == nil {
+		return ErrMfaNotEnrolled
+	}
+
+	if a.secrets == nil {
+		return ErrMfaUnavailable
+	}
+
+	secret, err := a.secrets.Open(user.TotpSecret)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt totp secret %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrMfaCodeInvalid
+	}
+
+	ttl := time.Duration(2*totpSkew+1) * totp.Period
+	if err := a.tokens.Use(ctx, "totp", fmt.Sprintf("%s:%d", user.Id, step), ttl); err != nil {
+		if errors.Is(err, storage.ErrTokenUsed) {
+			return fmt.Errorf("%w: already used", ErrMfaCodeInvalid)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// mfaEnforced reports whether holding the roles requires a second factor.
+// Nothing is enforced while TOTP is unavailable, admins could not enroll.
+func (a *AuthService) mfaEnforced(roles []entity.Role) bool {
+	return a.secrets != nil && a.cfg.Mfa.EnforceForAdmins && lo.Contains(roles, entity.RoleAdmin)
+}
+
+func (a *AuthService) mfaIssuer() string {
+	if a.cfg.Mfa.Issuer != "" {
+		return a.cfg.Mfa.Issuer
+	}
+	return a.cfg.App.Name
+}
@@ -0,0 +1,95 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/secret"
+	"mzhn/auth/internal/lib/totp"
+)
+
+func newTotpService(t *testing.T) *AuthService {
+	t.Helper()
+
+	box, err := secret.NewBox(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &AuthService{
+		tokens:  newMemoryTokens(),
+		secrets: box,
+		logger:  slog.Default(),
+	}
+}
+
+func newTotpUser(t *testing.T, a *AuthService, id string) (*entity.User, []byte) {
+	t.Helper()
+
+	raw, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := a.secrets.Seal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabledAt := time.Now()
+	return &entity.User{Id: id, TotpSecret: sealed, TotpEnabledAt: &enabledAt}, raw
+}
+
+func TestCheckTotpSingleUse(t *testing.T) {
+	ctx := context.Background()
+	a := newTotpService(t)
+	user, raw := newTotpUser(t, a, "user-1")
+	other, otherRaw := newTotpUser(t, a, "user-2")
+
+	// the next step stays within the window even if the current one ends meanwhile
+	step := totp.Step(time.Now())
+
+	tests := []struct {
+		name string
+		user *entity.User
+		code string
+		err  error
+	}{
+		{"current step", user, totp.Code(raw, step), nil},
+		{"current step again", user, totp.Code(raw, step), ErrMfaCodeInvalid},
+		{"next step", user, totp.Code(raw, step+1), nil},
+		{"next step again", user, totp.Code(raw, step+1), ErrMfaCodeInvalid},
+		{"step out of the window", user, totp.Code(raw, step+3), ErrMfaCodeInvalid},
+		{"code of another user", user, totp.Code(otherRaw, step), ErrMfaCodeInvalid},
+		{"same step of another user", other, totp.Code(otherRaw, step), nil},
+		{"malformed", user, "12345", ErrMfaCodeInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := a.checkTotp(ctx, tt.user, tt.code); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckTotpUnavailable(t *testing.T) {
+	ctx := context.Background()
+	a := newTotpService(t)
+	user, raw := newTotpUser(t, a, "user-1")
+	code := totp.Code(raw, totp.Step(time.Now()))
+
+	if err := a.checkTotp(ctx, &entity.User{Id: "user-2"}, code); !errors.Is(err, ErrMfaNotEnrolled) {
+		t.Fatalf("not enrolled: err = %v, want %v", err, ErrMfaNotEnrolled)
+	}
+
+	a.secrets = nil
+	if err := a.checkTotp(ctx, user, code); !errors.Is(err, ErrMfaUnavailable) {
+		t.Fatalf("without encryption key: err = %v, want %v", err, ErrMfaUnavailable)
+	}
+}
@@ -0,0 +1,278 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CreateOrganization creates an organization owned by the user, who becomes its admin.
+func (a *AuthService) CreateOrganization(ctx context.Context, req *dto.CreateOrganization) (*entity.Organization, error) {
+
+	log := a.logger.With(slog.String("method", "CreateOrganization"), slog.String("slug", req.Slug), slog.String("ownerId", req.OwnerId))
+
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	// slugs looking like ids could not be told apart from them
+	if _, err := uuid.Parse(req.Slug); err == nil || !slugPattern.MatchString(req.Slug) {
+		return nil, fmt.Errorf("%w: invalid slug %q", ErrInvalidRequest, req.Slug)
+	}
+
+	organization, err := a.organizations.Save(ctx, req)
+	if err != nil {
+		log.Error("save organization error", sl.Err(err))
+		if errors.Is(err, storage.ErrOrganizationExists) {
+			return nil, ErrOrganizationExists
+		}
+		return nil, err
+	}
+
+	if err := a.roleStorage.Add(ctx, &dto.AddRoles{
+		UserId:         req.OwnerId,
+		ActorId:        req.OwnerId,
+		OrganizationId: organization.Id,
+		Roles:          []entity.Role{entity.RoleAdmin},
+	}); err != nil {
+		log.Error("add owner role error", sl.Err(err))
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (a *AuthService) Organizations(ctx context.Context, userId string) ([]entity.Organization, error) {
+
+	log := a.logger.With(slog.String("method", "Organizations"), slog.String("userId", userId))
+
+	organizations, err := a.organizations.List(ctx, userId)
+	if err != nil {
+		log.Error("list organizations error", sl.Err(err))
+		return nil, err
+	}
+
+	return organizations, nil
+}
+
+// Members lists the organization to its members and global admins.
+func (a *AuthService) Members(ctx context.Context, organizationId, actorId string) ([]entity.Member, error) {
+
+	log := a.logger.With(slog.String("method", "Members"), slog.String("organizationId", organizationId), slog.String("actorId", actorId))
+
+	organization, err := a.organization(ctx, organizationId)
+	if err != nil {
+		log.Warn("organization not found", sl.Err(err))
+		return nil, err
+	}
+
+	if err := a.requireMember(ctx, organization.Id, actorId); err != nil {
+		if ok, cerr := a.isGlobalAdmin(ctx, actorId); cerr != nil || !ok {
+			return nil, err
+		}
+	}
+
+	members, err := a.organizations.Members(ctx, organization.Id)
+	if err != nil {
+		log.Error("list members error", sl.Err(err))
+		return nil, err
+	}
+
+	for i := range members {
+		roles, err := a.roleStorage.ListUser(ctx, members[i].UserId, organization.Id)
+		if err != nil {
+			log.Error("list member roles error", sl.Err(err))
+			return nil, err
+		}
+		members[i].Roles = roles
+	}
+
+	return members, nil
+}
+
+// AddMember lets an organization admin add a user with roles in the organization.
+func (a *AuthService) AddMember(ctx context.Context, req *dto.AddMember) error {
+
+	log := a.logger.With(slog.String("method", "AddMember"), slog.String("organizationId", req.OrganizationId), slog.String("userId", req.UserId))
+
+	organization, err := a.organizationAdmin(ctx, req.OrganizationId, req.ActorId)
+	if err != nil {
+		log.Warn("organization access denied", sl.Err(err))
+		return err
+	}
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return err
+	}
+
+	if err := a.checkRolesExist(ctx, req.Roles); err != nil {
+		return err
+	}
+
+	if err := a.organizations.AddMember(ctx, organization.Id, user.Id); err != nil {
+		log.Error("add member error", sl.Err(err))
+		return err
+	}
+
+	if err := a.roleStorage.Add(ctx, &dto.AddRoles{
+		UserId:         user.Id,
+		ActorId:        req.ActorId,
+		OrganizationId: organization.Id,
+		Roles:          req.Roles,
+	}); err != nil {
+		log.Error("add member roles error", sl.Err(err))
+		return roleError(err)
+	}
+
+	return nil
+}
+
+// RemoveMember drops the user and their roles from the organization.
+// Organization admins can remove anyone, members can leave on their own.
+func (a *AuthService) RemoveMember(ctx context.Context, req *dto.RemoveMember) error {
+
+	log := a.logger.With(slog.String("method", "RemoveMember"), slog.String("organizationId", req.OrganizationId), slog.String("userId", req.UserId))
+
+	var (
+		organization *entity.Organization
+		err          error
+	)
+
+	if req.ActorId == req.UserId {
+		organization, err = a.organization(ctx, req.OrganizationId)
+	} else {
+		organization, err = a.organizationAdmin(ctx, req.OrganizationId, req.ActorId)
+	}
+	if err != nil {
+		log.Warn("organization access denied", sl.Err(err))
+		return err
+	}
+
+	roles, err := a.roleStorage.ListUser(ctx, req.UserId, organization.Id)
+	if err != nil {
+		log.Error("list member roles error", sl.Err(err))
+		return err
+	}
+
+	if err := a.roleStorage.Remove(ctx, &dto.RemoveRoles{
+		UserId:         req.UserId,
+		ActorId:        req.ActorId,
+		OrganizationId: organization.Id,
+		Roles:          roles,
+	}); err != nil {
+		log.Error("remove member roles error", sl.Err(err))
+		return err
+	}
+
+	if err := a.organizations.RemoveMember(ctx, organization.Id, req.UserId); err != nil {
+		log.Warn("remove member error", sl.Err(err))
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+
+	// tokens scoped to the organization must not outlive the membership
+	if err := a.revocations.RevokeBefore(ctx, req.UserId, time.Now()); err != nil {
+		log.Error("revoke access tokens error", sl.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// SwitchOrganization rotates the session tokens into the organization scope.
+func (a *AuthService) SwitchOrganization(ctx context.Context, req *dto.SwitchOrganization) (*dto.Tokens, error) {
+
+	log := a.logger.With(slog.String("method", "SwitchOrganization"), slog.String("organizationId", req.OrganizationId))
+
+	return a.rotate(ctx, &dto.Refresh{RefreshToken: req.RefreshToken, Device: req.Device}, func(claims *entity.UserClaims, _ *entity.Session) error {
+		if req.OrganizationId == "" {
+			claims.OrganizationId = ""
+			return nil
+		}
+
+		organization, err := a.organization(ctx, req.OrganizationId)
+		if err != nil {
+			log.Warn("organization not found", sl.Err(err))
+			return err
+		}
+
+		if err := a.requireMember(ctx, organization.Id, claims.Id); err != nil {
+			log.Warn("not a member", slog.String("userId", claims.Id))
+			return err
+		}
+
+		claims.OrganizationId = organization.Id
+		return nil
+	})
+}
+
+func (a *AuthService) organization(ctx context.Context, slug string) (*entity.Organization, error) {
+	organization, err := a.organizations.Find(ctx, slug)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrganizationNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+// organizationAdmin resolves the organization if the actor administers it, directly or as a global admin.
+func (a *AuthService) organizationAdmin(ctx context.Context, slug, actorId string) (*entity.Organization, error) {
+	organization, err := a.organization(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := a.roleStorage.Check(ctx, &dto.CheckRoles{
+		UserId:         actorId,
+		OrganizationId: organization.Id,
+		Roles:          []entity.Role{entity.RoleAdmin},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrInsufficientPermission
+	}
+
+	return organization, nil
+}
+
+func (a *AuthService) requireMember(ctx context.Context, organizationId, userId string) error {
+	ok, err := a.organizations.IsMember(ctx, organizationId, userId)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+func (a *AuthService) isGlobalAdmin(ctx context.Context, userId string) (bool, error) {
+	return a.roleStorage.Check(ctx, &dto.CheckRoles{
+		UserId: userId,
+		Roles:  []entity.Role{entity.RoleAdmin},
+	})
+}
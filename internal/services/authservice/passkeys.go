@@ -0,0 +1,260 @@
+package authservice
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/lib/webauthn"
+	"mzhn/auth/internal/storage"
+)
+
+func newRelyingParty(cfg *config.Config) *webauthn.RelyingParty {
+	name := cfg.Webauthn.RpName
+	if name == "" {
+		name = cfg.App.Name
+	}
+
+	return &webauthn.RelyingParty{
+		Id:      cfg.Webauthn.RpId,
+		Name:    name,
+		Origins: cfg.Webauthn.Origins,
+		Timeout: time.Duration(cfg.Webauthn.Timeout) * time.Second,
+	}
+}
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create.
+// A passkey signs in without the password and the second factor, so both are checked again
+// and an access token alone cannot add one. The challenge is the registration grant,
+// it is valid for a single FinishPasskeyRegistration of the same user within the ceremony timeout.
+func (a *AuthService) BeginPasskeyRegistration(ctx context.Context, req *dto.BeginPasskeyRegistration) (*webauthn.CreationOptions, error) {
+
+	log := a.logger.With(slog.String("method", "BeginPasskeyRegistration"), slog.String("userId", req.UserId))
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if err := a.comparePassword(user.HashedPassword, req.Password); err != nil {
+		log.Warn("password mismatch")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.TotpEnabledAt != nil {
+		if req.Code == "" {
+			return nil, ErrMfaRequired
+		}
+
+		if err := a.checkSecondFactor(ctx, user, req.Code); err != nil {
+			log.Warn("code rejected", sl.Err(err))
+			return nil, err
+		}
+	}
+
+	passkeys, err := a.passkeys.List(ctx, user.Id)
+	if err != nil {
+		log.Error("list passkeys error", sl.Err(err))
+		return nil, err
+	}
+
+	exclude := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exclude = append(exclude, passkey.Id)
+	}
+
+	challenge, err := a.startCeremony(ctx, purposePasskeyRegistration, user.Id)
+	if err != nil {
+		log.Error("start ceremony error", sl.Err(err))
+		return nil, err
+	}
+
+	return a.relyingParty.CreationOptions(webauthn.User{
+		Id:          []byte(user.Id),
+		Name:        user.Email,
+		DisplayName: user.Email,
+	}, challenge, exclude), nil
+}
+
+func (a *AuthService) FinishPasskeyRegistration(ctx context.Context, req *dto.RegisterPasskey) (*entity.Passkey, error) {
+
+	log := a.logger.With(slog.String("method", "FinishPasskeyRegistration"), slog.String("userId", req.UserId))
+
+	challenge, subject, err := a.finishCeremony(ctx, purposePasskeyRegistration, req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		log.Warn("ceremony rejected", sl.Err(err))
+		return nil, err
+	}
+
+	if subject != req.UserId {
+		log.Warn("challenge issued to another user")
+		return nil, ErrPasskeyInvalid
+	}
+
+	credential, err := a.relyingParty.VerifyRegistration(challenge, req.Credential)
+	if err != nil {
+		log.Warn("registration rejected", sl.Err(err))
+		return nil, fmt.Errorf("%w: %w", ErrPasskeyInvalid, err)
+	}
+
+	passkey, err := a.passkeys.Save(ctx, &dto.CreatePasskey{
+		Id:        credential.Id,
+		UserId:    req.UserId,
+		PublicKey: credential.PublicKey,
+		SignCount: int64(credential.SignCount),
+		Name:      req.Name,
+	})
+	if err != nil {
+		log.Error("save passkey error", sl.Err(err))
+		if errors.Is(err, storage.ErrPasskeyExists) {
+			return nil, ErrPasskeyExists
+		}
+		return nil, err
+	}
+
+	log.Info("passkey registered")
+
+	return passkey, nil
+}
+
+func (a *AuthService) Passkeys(ctx context.Context, userId string) ([]entity.Passkey, error) {
+	return a.passkeys.List(ctx, userId)
+}
+
+func (a *AuthService) DeletePasskey(ctx context.Context, userId string, id []byte) error {
+
+	log := a.logger.With(slog.String("method", "DeletePasskey"), slog.String("userId", userId))
+
+	if err := a.passkeys.Delete(ctx, userId, id); err != nil {
+		log.Warn("delete passkey error", sl.Err(err))
+		if errors.Is(err, storage.ErrPasskeyNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+
+	log.Info("passkey deleted")
+
+	return nil
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get,
+// the user is picked by the authenticator from its discoverable credentials.
+func (a *AuthService) BeginPasskeyLogin(ctx context.Context) (*webauthn.RequestOptions, error) {
+
+	log := a.logger.With(slog.String("method", "BeginPasskeyLogin"))
+
+	challenge, err := a.startCeremony(ctx, purposePasskeyLogin, "")
+	if err != nil {
+		log.Error("start ceremony error", sl.Err(err))
+		return nil, err
+	}
+
+	return a.relyingParty.RequestOptions(challenge, nil), nil
+}
+
+// PasskeyLogin signs the user in without a password. A passkey requires user verification,
+// so it stands in for both the password and the second factor.
+func (a *AuthService) PasskeyLogin(ctx context.Context, req *dto.PasskeyLogin) (*dto.Tokens, error) {
+
+	log := a.logger.With(slog.String("method", "PasskeyLogin"))
+
+	challenge, _, err := a.finishCeremony(ctx, purposePasskeyLogin, req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		log.Warn("ceremony rejected", sl.Err(err))
+		return nil, err
+	}
+
+	passkey, err := a.passkeys.Find(ctx, req.Credential.RawId)
+	if err != nil {
+		log.Warn("find passkey error", sl.Err(err))
+		if errors.Is(err, storage.ErrPasskeyNotFound) {
+			return nil, ErrPasskeyInvalid
+		}
+		return nil, err
+	}
+
+	log = log.With(slog.String("userId", passkey.UserId))
+
+	if handle := req.Credential.Response.UserHandle; len(handle) > 0 && string(handle) != passkey.UserId {
+		log.Warn("user handle mismatch")
+		return nil, ErrPasskeyInvalid
+	}
+
+	signCount, err := a.relyingParty.VerifyAssertion(challenge, req.Credential, &webauthn.Credential{
+		Id:        passkey.Id,
+		PublicKey: passkey.PublicKey,
+		SignCount: uint32(passkey.SignCount),
+	})
+	if err != nil {
+		log.Warn("assertion rejected", sl.Err(err))
+		return nil, fmt.Errorf("%w: %w", ErrPasskeyInvalid, err)
+	}
+
+	if err := a.passkeys.Use(ctx, passkey.Id, passkey.SignCount, int64(signCount)); err != nil {
+		log.Warn("update passkey error", sl.Err(err))
+		if errors.Is(err, storage.ErrPasskeyNotFound) {
+			return nil, ErrPasskeyInvalid
+		}
+		return nil, err
+	}
+
+	user, err := a.userStorage.Find(ctx, passkey.UserId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if a.cfg.Registration.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	tokens, err := a.startSession(ctx, user, &req.Device, req.Audience)
+	if err != nil {
+		log.Error("start session error", sl.Err(err))
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// startCeremony generates a challenge and remembers it for the subject until the ceremony times out.
+func (a *AuthService) startCeremony(ctx context.Context, purpose, subject string) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(challenge)
+	if err := a.tokens.Save(ctx, purpose, key, subject, a.relyingParty.Timeout); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// finishCeremony takes the challenge signed by the client, each challenge completes at most one ceremony.
+func (a *AuthService) finishCeremony(ctx context.Context, purpose string, clientDataJSON []byte) ([]byte, string, error) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrPasskeyInvalid, err)
+	}
+
+	subject, err := a.tokens.Take(ctx, purpose, base64.RawURLEncoding.EncodeToString(challenge))
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return nil, "", fmt.Errorf("%w: unknown or expired challenge", ErrPasskeyInvalid)
+		}
+		return nil, "", err
+	}
+
+	return challenge, subject, nil
+}
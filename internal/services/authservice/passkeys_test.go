@@ -0,0 +1,287 @@
+package authservice
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/webauthn"
+	"mzhn/auth/internal/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryTokens keeps one-time tokens in memory the way the redis storage does.
+type memoryTokens struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func newMemoryTokens() *memoryTokens {
+	return &memoryTokens{tokens: make(map[string]string)}
+}
+
+func (m *memoryTokens) Save(_ context.Context, purpose, token, subject string, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[purpose+":"+token] = subject
+	return nil
+}
+
+func (m *memoryTokens) Take(_ context.Context, purpose, token string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subject, ok := m.tokens[purpose+":"+token]
+	if !ok {
+		return "", storage.ErrTokenNotFound
+	}
+
+	delete(m.tokens, purpose+":"+token)
+	return subject, nil
+}
+
+func (m *memoryTokens) Use(_ context.Context, purpose, token string, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tokens[purpose+":"+token]; ok {
+		return storage.ErrTokenUsed
+	}
+
+	m.tokens[purpose+":"+token] = ""
+	return nil
+}
+
+// memoryUsers finds users by id, the other methods are not used by the tests.
+type memoryUsers struct {
+	UserStorage
+	users map[string]*entity.User
+}
+
+func (m *memoryUsers) FindAny(_ context.Context, slug string) (*entity.User, error) {
+	user, ok := m.users[slug]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (m *memoryUsers) Find(ctx context.Context, slug string) (*entity.User, error) {
+	user, err := m.FindAny(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// memoryPasskeys holds no passkeys, the other methods are not used by the tests.
+type memoryPasskeys struct {
+	PasskeyStorage
+}
+
+func (memoryPasskeys) List(context.Context, string) ([]entity.Passkey, error) {
+	return nil, nil
+}
+
+func newUser(t *testing.T, id, password string) *entity.User {
+	t.Helper()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &entity.User{Id: id, Email: id + "@example.com", HashedPassword: string(hashed)}
+}
+
+func newCeremonyService() *AuthService {
+	return &AuthService{
+		tokens:       newMemoryTokens(),
+		relyingParty: &webauthn.RelyingParty{Id: "example.com", Origins: []string{"https://example.com"}, Timeout: time.Minute},
+		logger:       slog.Default(),
+	}
+}
+
+func clientDataJSON(t *testing.T, ceremony string, challenge []byte) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    "https://example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFinishCeremonySingleUse(t *testing.T) {
+	ctx := context.Background()
+	a := newCeremonyService()
+
+	challenge, err := a.startCeremony(ctx, purposePasskeyRegistration, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := clientDataJSON(t, "webauthn.create", challenge)
+
+	taken, subject, err := a.finishCeremony(ctx, purposePasskeyRegistration, data)
+	if err != nil {
+		t.Fatalf("first finish: %v", err)
+	}
+	if string(taken) != string(challenge) || subject != "user-1" {
+		t.Fatalf("finishCeremony() = %x, %q, want %x, %q", taken, subject, challenge, "user-1")
+	}
+
+	if _, _, err := a.finishCeremony(ctx, purposePasskeyRegistration, data); !errors.Is(err, ErrPasskeyInvalid) {
+		t.Fatalf("second finish: err = %v, want %v", err, ErrPasskeyInvalid)
+	}
+}
+
+func TestFinishCeremonyRejects(t *testing.T) {
+	ctx := context.Background()
+	a := newCeremonyService()
+
+	login, err := a.startCeremony(ctx, purposePasskeyLogin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknown, err := webauthn.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		purpose string
+		data    []byte
+	}{
+		{"unknown challenge", purposePasskeyLogin, clientDataJSON(t, "webauthn.get", unknown)},
+		{"challenge of another ceremony", purposePasskeyRegistration, clientDataJSON(t, "webauthn.create", login)},
+		{"malformed client data", purposePasskeyLogin, []byte("{")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := a.finishCeremony(ctx, tt.purpose, tt.data); !errors.Is(err, ErrPasskeyInvalid) {
+				t.Fatalf("err = %v, want %v", err, ErrPasskeyInvalid)
+			}
+		})
+	}
+
+	// failed attempts for another ceremony leave the challenge usable for its own one
+	if _, _, err := a.finishCeremony(ctx, purposePasskeyLogin, clientDataJSON(t, "webauthn.get", login)); err != nil {
+		t.Fatalf("login finish: %v", err)
+	}
+}
+
+func TestFinishPasskeyRegistrationOtherUser(t *testing.T) {
+	ctx := context.Background()
+	a := newCeremonyService()
+
+	challenge, err := a.startCeremony(ctx, purposePasskeyRegistration, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.FinishPasskeyRegistration(ctx, &dto.RegisterPasskey{
+		UserId: "user-2",
+		Credential: &webauthn.RegistrationCredential{
+			Response: webauthn.AttestationResponse{
+				ClientDataJSON: clientDataJSON(t, "webauthn.create", challenge),
+			},
+		},
+	})
+	if !errors.Is(err, ErrPasskeyInvalid) {
+		t.Fatalf("err = %v, want %v", err, ErrPasskeyInvalid)
+	}
+}
+
+func TestBeginPasskeyRegistrationStepUp(t *testing.T) {
+	ctx := context.Background()
+
+	enabledAt := time.Now()
+	withTotp := newUser(t, "user-2", "secret")
+	withTotp.TotpSecret = []byte("sealed")
+	withTotp.TotpEnabledAt = &enabledAt
+
+	tests := []struct {
+		name string
+		req  *dto.BeginPasskeyRegistration
+		err  error
+	}{
+		{"missing password", &dto.BeginPasskeyRegistration{UserId: "user-1"}, ErrInvalidCredentials},
+		{"wrong password", &dto.BeginPasskeyRegistration{UserId: "user-1", Password: "guess"}, ErrInvalidCredentials},
+		{"missing second factor", &dto.BeginPasskeyRegistration{UserId: "user-2", Password: "secret"}, ErrMfaRequired},
+		{"second factor unavailable", &dto.BeginPasskeyRegistration{UserId: "user-2", Password: "secret", Code: "123456"}, ErrMfaUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newCeremonyService()
+			tokens := a.tokens.(*memoryTokens)
+			a.userStorage = &memoryUsers{users: map[string]*entity.User{
+				"user-1": newUser(t, "user-1", "secret"),
+				"user-2": withTotp,
+			}}
+			a.passkeys = memoryPasskeys{}
+
+			if _, err := a.BeginPasskeyRegistration(ctx, tt.req); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			if len(tokens.tokens) != 0 {
+				t.Fatalf("registration challenge issued without step-up: %v", tokens.tokens)
+			}
+		})
+	}
+}
+
+func TestFinishPasskeyRegistrationRequiresGrant(t *testing.T) {
+	ctx := context.Background()
+	a := newCeremonyService()
+	a.userStorage = &memoryUsers{users: map[string]*entity.User{"user-1": newUser(t, "user-1", "secret")}}
+	a.passkeys = memoryPasskeys{}
+
+	options, err := a.BeginPasskeyRegistration(ctx, &dto.BeginPasskeyRegistration{UserId: "user-1", Password: "secret"})
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	forged, err := webauthn.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.FinishPasskeyRegistration(ctx, &dto.RegisterPasskey{
+		UserId: "user-1",
+		Credential: &webauthn.RegistrationCredential{
+			Response: webauthn.AttestationResponse{
+				ClientDataJSON: clientDataJSON(t, "webauthn.create", forged),
+			},
+		},
+	})
+	if !errors.Is(err, ErrPasskeyInvalid) {
+		t.Fatalf("err = %v, want %v", err, ErrPasskeyInvalid)
+	}
+
+	// the grant of the step-up is untouched by the rejected attempt
+	if _, _, err := a.finishCeremony(ctx, purposePasskeyRegistration, clientDataJSON(t, "webauthn.create", options.Challenge)); err != nil {
+		t.Fatalf("finish with grant: %v", err)
+	}
+}
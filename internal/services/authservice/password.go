@@ -0,0 +1,165 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/lib/logger/sl"
+)
+
+// ForgotPassword mails a single-use reset link to the user.
+// Unknown emails and disabled accounts are silently ignored, so the result does not reveal accounts.
+func (a *AuthService) ForgotPassword(ctx context.Context, email string) error {
+
+	log := a.logger.With(slog.String("method", "ForgotPassword"))
+
+	user, err := a.userStorage.Find(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrUserDisabled) {
+			log.Debug("user not found")
+			return nil
+		}
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	ttl := time.Duration(a.cfg.Password.ResetTTL) * time.Minute
+
+	token, err := a.issueActionToken(ctx, purposeResetPassword, user.Id, user.Email, ttl)
+	if err != nil {
+		log.Error("issue reset token error", sl.Err(err))
+		return err
+	}
+
+	link, err := withToken(a.cfg.Password.ResetUrl, token)
+	if err != nil {
+		log.Error("build reset link error", sl.Err(err))
+		return err
+	}
+
+	if err := a.mailer.Send(ctx, &dto.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Set a new password by following the link:\n\n%s\n\nThe link expires in %s. If you did not ask to reset your password, ignore this message.\n",
+			link, ttl,
+		),
+	}); err != nil {
+		log.Error("send reset mail error", sl.Err(err))
+		return err
+	}
+
+	log.Info("password reset requested", slog.String("userId", user.Id))
+
+	return nil
+}
+
+// ResetPassword sets a new password and signs the user out everywhere.
+func (a *AuthService) ResetPassword(ctx context.Context, req *dto.ResetPassword) error {
+
+	log := a.logger.With(slog.String("method", "ResetPassword"))
+
+	if req.Password == "" {
+		return ErrInvalidRequest
+	}
+
+	claims, err := a.consumeActionToken(ctx, purposeResetPassword, req.Token)
+	if err != nil {
+		log.Warn("reset token rejected", sl.Err(err))
+		return err
+	}
+
+	log = log.With(slog.String("userId", claims.Id))
+
+	user, err := a.userStorage.Find(ctx, claims.Id)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if !strings.EqualFold(user.Email, claims.Email) {
+		log.Warn("email changed since the token was issued")
+		return fmt.Errorf("%w: email changed", ErrTokenInvalid)
+	}
+
+	hashed, err := a.hash(req.Password)
+	if err != nil {
+		log.Error("hash password error", sl.Err(err))
+		return err
+	}
+
+	if _, err := a.userStorage.Update(ctx, user.Id, &dto.UpdateUser{Password: &hashed}); err != nil {
+		log.Error("update user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.signOutEverywhere(ctx, user.Id, ""); err != nil {
+		log.Error("sign out error", sl.Err(err))
+		return err
+	}
+
+	log.Info("password reset")
+
+	return nil
+}
+
+// ChangePassword replaces the password of a signed in user.
+// Every other session is deleted, the current one has to refresh its access token.
+func (a *AuthService) ChangePassword(ctx context.Context, req *dto.ChangePassword) error {
+
+	log := a.logger.With(slog.String("method", "ChangePassword"), slog.String("userId", req.UserId))
+
+	if req.NewPassword == "" {
+		return ErrInvalidRequest
+	}
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.comparePassword(user.HashedPassword, req.CurrentPassword); err != nil {
+		log.Warn("current password mismatch")
+		return ErrInvalidCredentials
+	}
+
+	hashed, err := a.hash(req.NewPassword)
+	if err != nil {
+		log.Error("hash password error", sl.Err(err))
+		return err
+	}
+
+	if _, err := a.userStorage.Update(ctx, user.Id, &dto.UpdateUser{Password: &hashed}); err != nil {
+		log.Error("update user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.signOutEverywhere(ctx, user.Id, req.SessionId); err != nil {
+		log.Error("sign out error", sl.Err(err))
+		return err
+	}
+
+	log.Info("password changed")
+
+	return nil
+}
+
+// signOutEverywhere deletes the sessions of the user except the given one
+// and invalidates the access tokens issued so far.
+func (a *AuthService) signOutEverywhere(ctx context.Context, userId, exceptSessionId string) error {
+	if _, err := a.sessionStorage.DeleteAll(ctx, userId, exceptSessionId); err != nil {
+		return fmt.Errorf("failed to delete sessions %w", err)
+	}
+
+	if err := a.revocations.RevokeBefore(ctx, userId, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke access tokens %w", err)
+	}
+
+	return nil
+}
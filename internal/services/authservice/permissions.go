@@ -0,0 +1,54 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+)
+
+func (a *AuthService) Permissions(ctx context.Context) ([]entity.PermissionDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "Permissions"))
+
+	permissions, err := a.permissions.List(ctx)
+	if err != nil {
+		log.Error("list permissions error", sl.Err(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (a *AuthService) CreatePermission(ctx context.Context, req *dto.CreatePermission) (*entity.PermissionDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "CreatePermission"), slog.String("permission", req.Name.String()))
+
+	if !namePattern.MatchString(req.Name.String()) {
+		return nil, fmt.Errorf("%w: invalid permission name %q", ErrInvalidRequest, req.Name)
+	}
+
+	permission, err := a.permissions.Save(ctx, req)
+	if err != nil {
+		log.Error("save permission error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	return permission, nil
+}
+
+// DeletePermission removes the permission together with its role grants.
+func (a *AuthService) DeletePermission(ctx context.Context, name entity.Permission) error {
+
+	log := a.logger.With(slog.String("method", "DeletePermission"), slog.String("permission", name.String()))
+
+	if err := a.permissions.Delete(ctx, name); err != nil {
+		log.Error("delete permission error", sl.Err(err))
+		return roleError(err)
+	}
+
+	return nil
+}
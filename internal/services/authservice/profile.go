@@ -2,9 +2,16 @@ package authservice
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
 )
 
 func (a *AuthService) Profile(ctx context.Context, userId string) (*entity.User, []entity.Role, error) {
@@ -18,7 +25,7 @@ func (a *AuthService) Profile(ctx context.Context, userId string) (*entity.User,
 	}
 	log.Debug("user found", slog.Any("user", user))
 
-	roles, err := a.roleStorage.ListUser(ctx, userId)
+	roles, err := a.roleStorage.ListUser(ctx, userId, "")
 	if err != nil {
 		log.Warn("cannot list user's roles", sl.Err(err))
 		return nil, nil, err
@@ -26,3 +33,135 @@ func (a *AuthService) Profile(ctx context.Context, userId string) (*entity.User,
 
 	return user, roles, nil
 }
+
+// UpdateProfile changes the names that are set, the email has its own confirmation flow.
+func (a *AuthService) UpdateProfile(ctx context.Context, req *dto.UpdateProfile) (*entity.User, error) {
+
+	log := a.logger.With(slog.String("method", "UpdateProfile"), slog.String("userId", req.UserId))
+
+	user, err := a.userStorage.Update(ctx, req.UserId, &dto.UpdateUser{
+		LastName:   req.LastName,
+		FirstName:  req.FirstName,
+		MiddleName: req.MiddleName,
+	})
+	if err != nil {
+		log.Error("update user error", sl.Err(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address,
+// the email is swapped only once the link is followed.
+func (a *AuthService) RequestEmailChange(ctx context.Context, req *dto.ChangeEmail) error {
+
+	log := a.logger.With(slog.String("method", "RequestEmailChange"), slog.String("userId", req.UserId))
+
+	if req.Email == "" {
+		return ErrInvalidRequest
+	}
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if err := a.comparePassword(user.HashedPassword, req.Password); err != nil {
+		log.Warn("password mismatch")
+		return ErrInvalidCredentials
+	}
+
+	if strings.EqualFold(user.Email, req.Email) {
+		return fmt.Errorf("%w: email is the same", ErrInvalidRequest)
+	}
+
+	// a disabled account still holds its email
+	if _, err := a.userStorage.Find(ctx, req.Email); err == nil || errors.Is(err, ErrUserDisabled) {
+		return ErrEmailTaken
+	} else if !errors.Is(err, ErrUserNotFound) {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	ttl := time.Duration(a.cfg.Registration.VerificationTTL) * time.Minute
+
+	token, err := a.issueActionToken(ctx, purposeChangeEmail, user.Id, req.Email, ttl)
+	if err != nil {
+		log.Error("issue email change token error", sl.Err(err))
+		return err
+	}
+
+	link, err := withToken(a.cfg.Profile.EmailChangeUrl, token)
+	if err != nil {
+		log.Error("build email change link error", sl.Err(err))
+		return err
+	}
+
+	if err := a.mailer.Send(ctx, &dto.Mail{
+		To:      req.Email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf(
+			"Confirm the new email address of your account by following the link:\n\n%s\n\nThe link expires in %s. If you did not ask for this change, ignore this message.\n",
+			link, ttl,
+		),
+	}); err != nil {
+		log.Error("send email change mail error", sl.Err(err))
+		return err
+	}
+
+	log.Info("email change requested")
+
+	return nil
+}
+
+// ConfirmEmailChange swaps the email of the token owner and marks it as verified.
+// Tokens carry the email, so every session is signed out to drop the old address.
+func (a *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+
+	log := a.logger.With(slog.String("method", "ConfirmEmailChange"))
+
+	claims, err := a.consumeActionToken(ctx, purposeChangeEmail, token)
+	if err != nil {
+		log.Warn("email change token rejected", sl.Err(err))
+		return err
+	}
+
+	log = log.With(slog.String("userId", claims.Id))
+
+	user, err := a.userStorage.Find(ctx, claims.Id)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	now := time.Now()
+	if _, err := a.userStorage.Update(ctx, user.Id, &dto.UpdateUser{
+		Email:           &claims.Email,
+		EmailVerifiedAt: &now,
+	}); err != nil {
+		log.Error("update user error", sl.Err(err))
+		if errors.Is(err, storage.ErrUserAlreadyExists) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	if err := a.signOutEverywhere(ctx, user.Id, ""); err != nil {
+		log.Error("sign out error", sl.Err(err))
+		return err
+	}
+
+	if err := a.mailer.Send(ctx, &dto.Mail{
+		To:      user.Email,
+		Subject: "Your email was changed",
+		Body:    fmt.Sprintf("The email address of your account was changed to %s.\n", claims.Email),
+	}); err != nil {
+		log.Warn("notify old email error", sl.Err(err))
+	}
+
+	log.Info("email changed")
+
+	return nil
+}
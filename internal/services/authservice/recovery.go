@@ -0,0 +1,133 @@
+package authservice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/lib/totp"
+)
+
+const recoveryCodeCount = 10
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, a valid second factor is required.
+// The codes are returned only once.
+func (a *AuthService) RegenerateRecoveryCodes(ctx context.Context, userId, code string) ([]string, error) {
+
+	log := a.logger.With(slog.String("method", "RegenerateRecoveryCodes"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return nil, err
+	}
+
+	if user.TotpEnabledAt == nil {
+		return nil, ErrMfaNotEnrolled
+	}
+
+	if err := a.checkSecondFactor(ctx, user, code); err != nil {
+		log.Warn("second factor rejected", sl.Err(err))
+		return nil, err
+	}
+
+	codes, err := a.issueRecoveryCodes(ctx, user.Id)
+	if err != nil {
+		log.Error("issue recovery codes error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("recovery codes regenerated")
+
+	return codes, nil
+}
+
+// RecoveryCodesLeft returns the number of recovery codes the user has not used yet.
+func (a *AuthService) RecoveryCodesLeft(ctx context.Context, userId string) (int, error) {
+	codes, err := a.recoveryCodes.ListUnused(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	return len(codes), nil
+}
+
+// issueRecoveryCodes generates a new set of codes and stores their bcrypt hashes.
+func (a *AuthService) issueRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		encoded := recoveryEncoding.EncodeToString(b)
+		code := encoded[:8] + "-" + encoded[8:]
+
+		hash, err := a.hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	if err := a.recoveryCodes.Replace(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// useRecoveryCode consumes a matching unused code of the user.
+func (a *AuthService) useRecoveryCode(ctx context.Context, user *entity.User, code string) error {
+	code = normalizeRecoveryCode(code)
+
+	codes, err := a.recoveryCodes.ListUnused(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range codes {
+		if a.comparePassword(c.CodeHash, code) != nil {
+			continue
+		}
+
+		ok, err := a.recoveryCodes.Use(ctx, c.Id)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("%w: already used", ErrMfaCodeInvalid)
+		}
+
+		a.logger.Info("recovery code used", slog.String("userId", user.Id), slog.Int("left", len(codes)-1))
+
+		return nil
+	}
+
+	return ErrMfaCodeInvalid
+}
+
+// checkSecondFactor accepts either a TOTP code or one of the recovery codes of the user.
+func (a *AuthService) checkSecondFactor(ctx context.Context, user *entity.User, code string) error {
+	if user.TotpEnabledAt != nil && len(normalizeRecoveryCode(code)) > totp.Digits {
+		return a.useRecoveryCode(ctx, user, code)
+	}
+	return a.checkTotp(ctx, user, code)
+}
+
+// normalizeRecoveryCode lets users type codes without the dash and in any case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,170 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/entity"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryRecoveryCodes marks codes used the way the postgres storage does, stale
+// lists used codes too, like a read racing a concurrent Use.
+type memoryRecoveryCodes struct {
+	mu    sync.Mutex
+	codes []entity.RecoveryCode
+	stale bool
+}
+
+func (m *memoryRecoveryCodes) Replace(_ context.Context, userId string, hashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.codes = m.codes[:0]
+	for i, hash := range hashes {
+		m.codes = append(m.codes, entity.RecoveryCode{Id: int64(i + 1), UserId: userId, CodeHash: hash})
+	}
+	return nil
+}
+
+func (m *memoryRecoveryCodes) ListUnused(_ context.Context, userId string) ([]entity.RecoveryCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var codes []entity.RecoveryCode
+	for _, code := range m.codes {
+		if code.UserId == userId && (code.UsedAt == nil || m.stale) {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (m *memoryRecoveryCodes) Use(_ context.Context, id int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.codes {
+		if m.codes[i].Id == id && m.codes[i].UsedAt == nil {
+			now := time.Now()
+			m.codes[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newRecoveryService(t *testing.T) (*AuthService, *entity.User, []string) {
+	t.Helper()
+
+	a := newTotpService(t)
+	a.recoveryCodes = &memoryRecoveryCodes{}
+	a.cfg = &config.Config{Bcrypt: config.Bcrypt{Cost: bcrypt.MinCost}}
+
+	user, _ := newTotpUser(t, a, "user-1")
+
+	codes, err := a.issueRecoveryCodes(context.Background(), user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a, user, codes
+}
+
+func TestIssueRecoveryCodes(t *testing.T) {
+	a, user, codes := newRecoveryService(t)
+
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("issued %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if len(code) != 17 || code[8] != '-' {
+			t.Fatalf("code %q is not formatted as xxxxxxxx-xxxxxxxx", code)
+		}
+		if seen[code] {
+			t.Fatalf("code %q issued twice", code)
+		}
+		seen[code] = true
+	}
+
+	left, err := a.RecoveryCodesLeft(context.Background(), user.Id)
+	if err != nil || left != recoveryCodeCount {
+		t.Fatalf("RecoveryCodesLeft() = %d, %v, want %d", left, err, recoveryCodeCount)
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	a, user, codes := newRecoveryService(t)
+
+	tests := []struct {
+		name string
+		code string
+		err  error
+		left int
+	}{
+		{"unused code", codes[0], nil, recoveryCodeCount - 1},
+		{"used up code", codes[0], ErrMfaCodeInvalid, recoveryCodeCount - 1},
+		{"without dash and in upper case", strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), nil, recoveryCodeCount - 2},
+		{"used up code typed differently", strings.ToUpper(codes[1]), ErrMfaCodeInvalid, recoveryCodeCount - 2},
+		{"unknown code", "aaaaaaaa-aaaaaaaa", ErrMfaCodeInvalid, recoveryCodeCount - 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := a.checkSecondFactor(ctx, user, tt.code); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			left, err := a.RecoveryCodesLeft(ctx, user.Id)
+			if err != nil || left != tt.left {
+				t.Fatalf("RecoveryCodesLeft() = %d, %v, want %d", left, err, tt.left)
+			}
+		})
+	}
+}
+
+func TestUseRecoveryCodeConcurrently(t *testing.T) {
+	ctx := context.Background()
+	a, user, codes := newRecoveryService(t)
+	recoveryCodes := a.recoveryCodes.(*memoryRecoveryCodes)
+
+	if err := a.useRecoveryCode(ctx, user, codes[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// the code was listed as unused but another request consumed it first
+	recoveryCodes.stale = true
+	if err := a.useRecoveryCode(ctx, user, codes[0]); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("err = %v, want %v", err, ErrMfaCodeInvalid)
+	}
+}
+
+func TestRegenerateRecoveryCodesReplaces(t *testing.T) {
+	ctx := context.Background()
+	a, user, codes := newRecoveryService(t)
+	a.userStorage = &memoryUsers{users: map[string]*entity.User{user.Id: user}}
+
+	fresh, err := a.RegenerateRecoveryCodes(ctx, user.Id, codes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range codes[1:] {
+		if err := a.useRecoveryCode(ctx, user, code); !errors.Is(err, ErrMfaCodeInvalid) {
+			t.Fatalf("replaced code %q: err = %v, want %v", code, err, ErrMfaCodeInvalid)
+		}
+	}
+
+	if err := a.useRecoveryCode(ctx, user, fresh[0]); err != nil {
+		t.Fatalf("fresh code: %v", err)
+	}
+}
@@ -2,47 +2,113 @@ package authservice
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/jwt"
 	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+	"time"
 )
 
 func (a *AuthService) Refresh(ctx context.Context, req *dto.Refresh) (*dto.Tokens, error) {
+	return a.rotate(ctx, req, func(claims *entity.UserClaims, session *entity.Session) error {
+		// sessions of OAuth clients are refreshed through the token endpoint, where the client authenticates
+		if session.ClientId != "" {
+			return ErrTokenInvalid
+		}
+
+		return a.checkOrganization(ctx, claims)
+	})
+}
+
+// checkOrganization falls back to the personal context once the user has left the organization of the token.
+func (a *AuthService) checkOrganization(ctx context.Context, claims *entity.UserClaims) error {
+	if claims.OrganizationId == "" {
+		return nil
+	}
+
+	err := a.requireMember(ctx, claims.OrganizationId, claims.Id)
+	if errors.Is(err, ErrNotMember) {
+		claims.OrganizationId = ""
+		return nil
+	}
+	return err
+}
+
+// rotate exchanges a refresh token for a new pair, scope may reject the session or adjust the claims of the new tokens.
+func (a *AuthService) rotate(ctx context.Context, req *dto.Refresh, scope func(claims *entity.UserClaims, session *entity.Session) error) (*dto.Tokens, error) {
 	log := a.logger.With("method", "AuthService.Refresh")
 
 	log.Debug("refreshing", slog.Any("req", req))
 
-	claims, err := jwt.Verify(req.RefreshToken, a.cfg.Jwt.RefreshSecret)
+	claims, err := jwt.Verify(req.RefreshToken, a.refreshKey, a.verifyOptions())
 	if err != nil {
 		log.Error("refresh token not valid", sl.Err(err))
-		if err := a.sessionStorage.Delete(ctx, req.RefreshToken); err != nil {
-			log.Error("delete session error", sl.Err(err))
-			return nil, err
+		return nil, ErrTokenInvalid
+	}
+
+	session, err := a.sessionStorage.Check(ctx, claims.SessionId, req.RefreshToken)
+	if err != nil {
+		log.Error("session check failed", sl.Err(err))
+		if errors.Is(err, storage.ErrSessionReused) {
+			return nil, a.revokeFamily(ctx, claims, &req.Device)
 		}
-		return nil, err
+		return nil, ErrTokenInvalid
 	}
 
-	if err := a.sessionStorage.Check(ctx, claims.Id, req.RefreshToken); err != nil {
-		log.Error("session not found", sl.Err(err))
+	if err := scope(claims, session); err != nil {
+		log.Warn("scope rejected", sl.Err(err))
 		return nil, err
 	}
 
-	tokens, err := a.generateJwtPair(claims)
+	tokens, err := a.generateJwtPair(ctx, claims)
 	if err != nil {
 		log.Error("generate jwt pair error", sl.Err(err))
 		return nil, err
 	}
 
-	if err := a.sessionStorage.Delete(ctx, claims.Id); err != nil {
-		log.Error("delete session error", sl.Err(err))
-		return nil, err
-	}
+	session.Ip = req.Device.Ip
+	session.UserAgent = req.Device.UserAgent
+	session.LastUsedAt = time.Now()
 
-	if err := a.sessionStorage.Save(ctx, claims.Id, tokens.RefreshToken); err != nil {
-		log.Error("save session error", sl.Err(err))
+	if err := a.sessionStorage.Rotate(ctx, session, req.RefreshToken, tokens.RefreshToken); err != nil {
+		log.Error("rotate session error", sl.Err(err))
+		if errors.Is(err, storage.ErrSessionReused) {
+			return nil, a.revokeFamily(ctx, claims, &req.Device)
+		}
+		if errors.Is(err, storage.ErrSessionInvalid) || errors.Is(err, storage.ErrSessionNotFound) {
+			return nil, ErrTokenInvalid
+		}
 		return nil, err
 	}
 
 	return tokens, nil
 }
+
+// revokeFamily drops the whole session once an already rotated refresh token
+// is presented again, since either the client or an attacker holds a stolen copy.
+func (a *AuthService) revokeFamily(ctx context.Context, claims *entity.UserClaims, device *dto.Device) error {
+	log := a.logger.With(slog.String("method", "revokeFamily"), slog.String("userId", claims.Id), slog.String("sessionId", claims.SessionId))
+
+	log.Warn("refresh token reuse detected, revoking session")
+
+	if err := a.endSession(ctx, claims.SessionId); err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+		log.Error("delete session error", sl.Err(err))
+		return err
+	}
+
+	if err := a.eventsStorage.Publish(ctx, &entity.SecurityEvent{
+		Type:      entity.EventRefreshTokenReuse,
+		UserId:    claims.Id,
+		SessionId: claims.SessionId,
+		Ip:        device.Ip,
+		UserAgent: device.UserAgent,
+		At:        time.Now(),
+	}); err != nil {
+		log.Error("publish security event error", sl.Err(err))
+	}
+
+	return ErrTokenReused
+}
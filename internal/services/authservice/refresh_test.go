@@ -0,0 +1,295 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/storage"
+)
+
+// memorySessions keeps the current refresh token of every session and the tokens
+// it was rotated from, the way the redis storage detects reuse.
+type memorySessions struct {
+	SessionsStorage
+	mu       sync.Mutex
+	sessions map[string]*entity.Session
+	current  map[string]string
+	rotated  map[string]map[string]bool
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{
+		sessions: make(map[string]*entity.Session),
+		current:  make(map[string]string),
+		rotated:  make(map[string]map[string]bool),
+	}
+}
+
+func (m *memorySessions) Save(_ context.Context, session *entity.Session, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *session
+	m.sessions[session.Id] = &copied
+	m.current[session.Id] = token
+	m.rotated[session.Id] = make(map[string]bool)
+	return nil
+}
+
+func (m *memorySessions) match(sessionId, token string) (*entity.Session, error) {
+	session, ok := m.sessions[sessionId]
+	if !ok {
+		return nil, storage.ErrSessionNotFound
+	}
+
+	if m.current[sessionId] == token {
+		copied := *session
+		return &copied, nil
+	}
+
+	if m.rotated[sessionId][token] {
+		return nil, storage.ErrSessionReused
+	}
+
+	return nil, storage.ErrSessionInvalid
+}
+
+func (m *memorySessions) Check(_ context.Context, sessionId, token string) (*entity.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.match(sessionId, token)
+}
+
+func (m *memorySessions) Rotate(_ context.Context, session *entity.Session, oldToken, newToken string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.match(session.Id, oldToken); err != nil {
+		return err
+	}
+
+	copied := *session
+	m.sessions[session.Id] = &copied
+	m.rotated[session.Id][oldToken] = true
+	m.current[session.Id] = newToken
+	return nil
+}
+
+func (m *memorySessions) Delete(_ context.Context, sessionId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[sessionId]; !ok {
+		return storage.ErrSessionNotFound
+	}
+
+	delete(m.sessions, sessionId)
+	delete(m.current, sessionId)
+	delete(m.rotated, sessionId)
+	return nil
+}
+
+// memoryRevocations denies revoked access tokens and the ones of ended sessions,
+// the other methods are not used by the tests.
+type memoryRevocations struct {
+	RevocationStorage
+	tokens   []string
+	sessions []string
+}
+
+func (m *memoryRevocations) Revoke(_ context.Context, tokenId string, _ time.Duration) error {
+	m.tokens = append(m.tokens, tokenId)
+	return nil
+}
+
+func (m *memoryRevocations) RevokeSession(_ context.Context, sessionId string, _ time.Duration) error {
+	m.sessions = append(m.sessions, sessionId)
+	return nil
+}
+
+func (m *memoryRevocations) IsRevoked(_ context.Context, _, sessionId, tokenId string, _ time.Time) (bool, error) {
+	return slices.Contains(m.tokens, tokenId) || slices.Contains(m.sessions, sessionId), nil
+}
+
+type memoryEvents struct {
+	events []entity.SecurityEvent
+}
+
+func (m *memoryEvents) Publish(_ context.Context, event *entity.SecurityEvent) error {
+	m.events = append(m.events, *event)
+	return nil
+}
+
+// memoryRoles grants no roles, the other methods are not used by the tests.
+type memoryRoles struct {
+	RoleStorage
+}
+
+func (memoryRoles) ListUser(context.Context, string, string) ([]entity.Role, error) {
+	return nil, nil
+}
+
+func (memoryRoles) ListUserPermissions(context.Context, string, string) ([]entity.Permission, error) {
+	return nil, nil
+}
+
+func newRefreshService(t *testing.T) *AuthService {
+	t.Helper()
+
+	keyring, err := jwt.NewKeyring(&jwt.RingKey{Key: jwt.NewHMACKey("access", []byte("access-secret"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &AuthService{
+		sessionStorage: newMemorySessions(),
+		revocations:    &memoryRevocations{},
+		eventsStorage:  &memoryEvents{},
+		roleStorage:    memoryRoles{},
+		keyring:        keyring,
+		refreshKey:     jwt.NewHMACKey("", []byte("refresh-secret")),
+		cfg: &config.Config{
+			App: config.App{Name: "auth"},
+			Jwt: config.Jwt{AccessTTL: 5, RefreshTTL: 60},
+		},
+		logger: slog.Default(),
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	a := newRefreshService(t)
+
+	tokens, err := a.startSession(ctx, &entity.User{Id: "user-1", Email: "user-1@example.com"}, &dto.Device{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		next, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: tokens.RefreshToken})
+		if err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+		if next.RefreshToken == tokens.RefreshToken {
+			t.Fatalf("refresh %d: refresh token not rotated", i)
+		}
+		tokens = next
+	}
+
+	if _, err := a.verifyAccessToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("access token of the last refresh: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	a := newRefreshService(t)
+	sessions := a.sessionStorage.(*memorySessions)
+	revocations := a.revocations.(*memoryRevocations)
+	events := a.eventsStorage.(*memoryEvents)
+
+	first, err := a.startSession(ctx, &entity.User{Id: "user-1", Email: "user-1@example.com"}, &dto.Device{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: second.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := jwt.Verify(first.RefreshToken, a.refreshKey, a.verifyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rotated token from anywhere in the family ends the session
+	if _, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: first.RefreshToken, Device: dto.Device{Ip: "203.0.113.7"}}); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reuse: err = %v, want %v", err, ErrTokenReused)
+	}
+
+	if _, ok := sessions.sessions[claims.SessionId]; ok {
+		t.Fatal("session survived refresh token reuse")
+	}
+
+	if len(revocations.sessions) != 1 || revocations.sessions[0] != claims.SessionId {
+		t.Fatalf("revoked sessions = %v, want [%s]", revocations.sessions, claims.SessionId)
+	}
+
+	if len(events.events) != 1 || events.events[0].Type != entity.EventRefreshTokenReuse || events.events[0].Ip != "203.0.113.7" {
+		t.Fatalf("events = %+v, want one %s event", events.events, entity.EventRefreshTokenReuse)
+	}
+
+	if _, err := a.verifyAccessToken(ctx, third.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("access token of the family: err = %v, want %v", err, ErrTokenRevoked)
+	}
+
+	// the latest token of the family is dead as well
+	for _, token := range []string{third.RefreshToken, second.RefreshToken} {
+		if _, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: token}); !errors.Is(err, ErrTokenInvalid) {
+			t.Fatalf("refresh after reuse: err = %v, want %v", err, ErrTokenInvalid)
+		}
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	ctx := context.Background()
+	a := newRefreshService(t)
+	revocations := a.revocations.(*memoryRevocations)
+
+	tokens, err := a.startSession(ctx, &entity.User{Id: "user-1", Email: "user-1@example.com"}, &dto.Device{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := jwt.Verify(tokens.RefreshToken, a.refreshKey, a.verifyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// validly signed for the session but never handed out by it
+	forged, err := a.generateJwtPair(ctx, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"never issued", forged.RefreshToken},
+		{"access token", tokens.AccessToken},
+		{"garbage", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: tt.token}); !errors.Is(err, ErrTokenInvalid) {
+				t.Fatalf("err = %v, want %v", err, ErrTokenInvalid)
+			}
+		})
+	}
+
+	if len(revocations.sessions) != 0 {
+		t.Fatalf("revoked sessions = %v, want none", revocations.sessions)
+	}
+
+	if _, err := a.Refresh(ctx, &dto.Refresh{RefreshToken: tokens.RefreshToken}); err != nil {
+		t.Fatalf("refresh with the issued token: %v", err)
+	}
+}
@@ -11,13 +11,30 @@ import (
 	"mzhn/auth/internal/storage"
 )
 
+// Register creates a user with the configured default role.
+// Elevated roles come only from an invite approved by an admin.
+// When verified emails are required no session is started and the tokens are nil.
 func (a *AuthService) Register(ctx context.Context, req *dto.CreateUser) (tokens *dto.Tokens, err error) {
 
 	log := a.logger.With("method", "AuthService.Register")
 
-	log.Debug("registering", slog.Any("req", req))
+	log.Debug("registering", slog.String("email", req.Email))
 
-	log.Debug("hashing password", slog.String("password", req.Password))
+	var invite *entity.Invite
+	if req.Invite != "" {
+		invite, err = a.redeemInvite(ctx, req.Invite, req.Email)
+		if err != nil {
+			log.Warn("invite rejected", sl.Err(err))
+			return nil, err
+		}
+
+		if err := a.checkRolesExist(ctx, invite.Roles); err != nil {
+			log.Warn("invite role no longer exists", sl.Err(err))
+			return nil, err
+		}
+	}
+
+	log.Debug("hashing password")
 	req.Password, err = a.hash(req.Password)
 	if err != nil {
 		log.Error("hash password error", sl.Err(err))
@@ -36,26 +53,43 @@ func (a *AuthService) Register(ctx context.Context, req *dto.CreateUser) (tokens
 		return nil, err
 	}
 
-	if err := a.roleStorage.Add(ctx, &dto.AddRoles{
-		UserId: user.Id,
-		Roles:  req.Roles,
-	}); err != nil {
-		log.Error("add roles error", sl.Err(err))
-		return nil, fmt.Errorf("cannot add roles %w", err)
+	if role := entity.Role(a.cfg.Registration.DefaultRole); role != "" {
+		if err := a.roleStorage.Add(ctx, &dto.AddRoles{
+			UserId: user.Id,
+			Roles:  []entity.Role{role},
+		}); err != nil {
+			log.Error("add default role error", sl.Err(err))
+			return nil, fmt.Errorf("cannot add roles %w", err)
+		}
 	}
 
-	log.Debug("generating jwt pair")
-	tokens, err = a.generateJwtPair(&entity.UserClaims{
-		Id:    user.Id,
-		Email: user.Email,
-	})
-	if err != nil {
-		log.Error("generate jwt pair error", sl.Err(err))
-		return nil, err
+	if invite != nil {
+		if err := a.roleStorage.Add(ctx, &dto.AddRoles{
+			UserId:  user.Id,
+			ActorId: invite.CreatedBy,
+			Roles:   invite.Roles,
+		}); err != nil {
+			log.Error("add invite roles error", sl.Err(err))
+			return nil, fmt.Errorf("cannot add roles %w", err)
+		}
+	}
+
+	if err := a.sendVerification(ctx, user); err != nil {
+		log.Error("send verification error", sl.Err(err))
+		if a.cfg.Registration.RequireVerifiedEmail {
+			return nil, err
+		}
 	}
 
-	if a.sessionStorage.Save(ctx, user.Id, tokens.RefreshToken); err != nil {
-		log.Error("save session error", sl.Err(err))
+	if a.cfg.Registration.RequireVerifiedEmail {
+		log.Debug("waiting for email verification")
+		return nil, nil
+	}
+
+	log.Debug("starting session")
+	tokens, err = a.startSession(ctx, user, &req.Device, req.Audience)
+	if err != nil {
+		log.Error("start session error", sl.Err(err))
 		return nil, err
 	}
 
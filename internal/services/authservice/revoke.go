@@ -0,0 +1,90 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+)
+
+// RevokeToken implements RFC 7009. Revoking a refresh token ends its session,
+// revoking an access token denies it until it expires. Unknown tokens are ignored,
+// tokens issued to another client are refused. Public clients identify by their id alone.
+func (a *AuthService) RevokeToken(ctx context.Context, req *dto.RevokeToken) error {
+
+	log := a.logger.With(slog.String("method", "RevokeToken"), slog.String("clientId", req.ClientId))
+
+	client, err := a.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		log.Warn("client authentication failed", sl.Err(err))
+		return err
+	}
+
+	for _, tokenType := range tokenTypes(req.TokenTypeHint) {
+		switch tokenType {
+		case TokenTypeAccess:
+			claims, err := a.verifyAccessToken(ctx, req.Token)
+			if err != nil {
+				continue
+			}
+
+			if err := a.requireIssuedTo(ctx, claims, client.Id); err != nil {
+				log.Warn("access token issued to another client", sl.Err(err))
+				return err
+			}
+
+			log.Info("revoking access token", slog.String("jti", claims.TokenId))
+			return a.revocations.Revoke(ctx, claims.TokenId, time.Until(claims.ExpiresAt))
+		case TokenTypeRefresh:
+			claims, err := a.verifyRefreshToken(ctx, req.Token)
+			if err != nil {
+				continue
+			}
+
+			if err := a.requireIssuedTo(ctx, claims, client.Id); err != nil {
+				log.Warn("refresh token issued to another client", sl.Err(err))
+				return err
+			}
+
+			log.Info("revoking session", slog.String("sessionId", claims.SessionId))
+			if err := a.endSession(ctx, claims.SessionId); err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+				log.Error("delete session error", sl.Err(err))
+				return err
+			}
+			return nil
+		}
+	}
+
+	log.Debug("token not active, nothing to revoke")
+	return nil
+}
+
+// requireIssuedTo checks that the token belongs to the client, either as its own
+// client credentials token or as a token of a session the client started.
+func (a *AuthService) requireIssuedTo(ctx context.Context, claims *entity.UserClaims, clientId string) error {
+	if claims.ClientId != "" {
+		if claims.ClientId != clientId {
+			return ErrUnauthorizedClient
+		}
+		return nil
+	}
+
+	session, err := a.sessionStorage.Find(ctx, claims.SessionId)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return ErrUnauthorizedClient
+		}
+		return err
+	}
+
+	if session.ClientId != clientId {
+		return ErrUnauthorizedClient
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+)
+
+// CheckRoles reports whether the user has at least one of the roles.
+func (a *AuthService) CheckRoles(ctx context.Context, req *dto.CheckRoles) (bool, error) {
+
+	log := a.logger.With(slog.String("method", "CheckRoles"), slog.String("userId", req.UserId))
+
+	if _, err := a.userStorage.Find(ctx, req.UserId); err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return false, err
+	}
+
+	ok, err := a.roleStorage.Check(ctx, req)
+	if err != nil {
+		log.Error("check roles error", sl.Err(err))
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// namePattern restricts role and permission names, e.g. "support" or "orders:refund".
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]*$`)
+
+func (a *AuthService) Roles(ctx context.Context) ([]entity.RoleDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "Roles"))
+
+	roles, err := a.roleStorage.List(ctx)
+	if err != nil {
+		log.Error("list roles error", sl.Err(err))
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (a *AuthService) Role(ctx context.Context, name entity.Role) (*entity.RoleDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "Role"), slog.String("role", name.String()))
+
+	role, err := a.roleStorage.Find(ctx, name)
+	if err != nil {
+		log.Warn("find role error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	return role, nil
+}
+
+func (a *AuthService) CreateRole(ctx context.Context, req *dto.CreateRole) (*entity.RoleDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "CreateRole"), slog.String("role", req.Name.String()))
+
+	if !namePattern.MatchString(req.Name.String()) {
+		return nil, fmt.Errorf("%w: invalid role name %q", ErrInvalidRequest, req.Name)
+	}
+
+	role, err := a.roleStorage.Save(ctx, req)
+	if err != nil {
+		log.Error("save role error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	return role, nil
+}
+
+func (a *AuthService) UpdateRole(ctx context.Context, req *dto.UpdateRole) (*entity.RoleDefinition, error) {
+
+	log := a.logger.With(slog.String("method", "UpdateRole"), slog.String("role", req.Name.String()))
+
+	role, err := a.roleStorage.Update(ctx, req)
+	if err != nil {
+		log.Error("update role error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	return role, nil
+}
+
+func (a *AuthService) DeleteRole(ctx context.Context, name entity.Role) error {
+
+	log := a.logger.With(slog.String("method", "DeleteRole"), slog.String("role", name.String()))
+
+	if name.Builtin() {
+		return fmt.Errorf("%w: built-in role %q cannot be deleted", ErrInvalidRequest, name)
+	}
+
+	if err := a.roleStorage.Delete(ctx, name); err != nil {
+		log.Error("delete role error", sl.Err(err))
+		return roleError(err)
+	}
+
+	return nil
+}
+
+// roleError maps storage errors of roles and permissions to service errors.
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrRoleNotFound):
+		return ErrRoleNotFound
+	case errors.Is(err, storage.ErrRoleAlreadyExists):
+		return ErrRoleExists
+	case errors.Is(err, storage.ErrPermissionNotFound):
+		return ErrPermissionNotFound
+	case errors.Is(err, storage.ErrPermissionExists):
+		return ErrPermissionExists
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+)
+
+func (a *AuthService) Sessions(ctx context.Context, userId string) ([]entity.Session, error) {
+
+	log := a.logger.With(slog.String("method", "Sessions"), slog.String("userId", userId))
+
+	sessions, err := a.sessionStorage.List(ctx, userId)
+	if err != nil {
+		log.Warn("cannot list sessions", sl.Err(err))
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (a *AuthService) RevokeSession(ctx context.Context, userId, sessionId string) error {
+
+	log := a.logger.With(slog.String("method", "RevokeSession"), slog.String("userId", userId), slog.String("sessionId", sessionId))
+
+	session, err := a.sessionStorage.Find(ctx, sessionId)
+	if err != nil {
+		log.Warn("cannot find session", sl.Err(err))
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	if session.UserId != userId {
+		log.Warn("session belongs to another user")
+		return ErrSessionNotFound
+	}
+
+	if err := a.endSession(ctx, sessionId); err != nil {
+		log.Warn("failed to delete session", sl.Err(err))
+		return fmt.Errorf("failed to delete session %w", err)
+	}
+
+	return nil
+}
+
+func (a *AuthService) RevokeOtherSessions(ctx context.Context, userId, currentSessionId string) error {
+
+	log := a.logger.With(slog.String("method", "RevokeOtherSessions"), slog.String("userId", userId))
+
+	deleted, err := a.sessionStorage.DeleteAll(ctx, userId, currentSessionId)
+	if err != nil {
+		log.Warn("failed to delete sessions", sl.Err(err))
+		return fmt.Errorf("failed to delete sessions %w", err)
+	}
+
+	for _, sessionId := range deleted {
+		if err := a.revocations.RevokeSession(ctx, sessionId, a.accessTTL()); err != nil {
+			log.Warn("failed to revoke session", slog.String("sessionId", sessionId), sl.Err(err))
+			return fmt.Errorf("failed to revoke session %w", err)
+		}
+	}
+
+	return nil
+}
+
+// endSession deletes the session and denies the access tokens issued for it, which would
+// otherwise stay valid until they expire.
+func (a *AuthService) endSession(ctx context.Context, sessionId string) error {
+	if err := a.revocations.RevokeSession(ctx, sessionId, a.accessTTL()); err != nil {
+		return err
+	}
+
+	return a.sessionStorage.Delete(ctx, sessionId)
+}
@@ -0,0 +1,233 @@
+package authservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/jwt"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/storage"
+
+	"github.com/samber/lo"
+)
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// Token is the OAuth 2.0 token endpoint.
+func (a *AuthService) Token(ctx context.Context, req *dto.Token) (*dto.TokenResponse, error) {
+	switch req.GrantType {
+	case GrantAuthorizationCode:
+		return a.exchangeCode(ctx, req)
+	case GrantRefreshToken:
+		return a.refreshGrant(ctx, req)
+	case GrantClientCredentials:
+		return a.clientCredentials(ctx, req)
+	default:
+		return nil, ErrUnsupportedGrantType
+	}
+}
+
+func (a *AuthService) exchangeCode(ctx context.Context, req *dto.Token) (*dto.TokenResponse, error) {
+
+	log := a.logger.With(slog.String("method", "exchangeCode"), slog.String("clientId", req.ClientId))
+
+	client, err := a.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		log.Warn("client authentication failed", sl.Err(err))
+		return nil, err
+	}
+
+	if !lo.Contains(client.GrantTypes, GrantAuthorizationCode) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	authCode, err := a.authCodes.Take(ctx, req.Code)
+	if err != nil {
+		log.Warn("take code error", sl.Err(err))
+		if errors.Is(err, storage.ErrAuthCodeNotFound) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+
+	if authCode.ClientId != req.ClientId || authCode.RedirectUri != req.RedirectUri {
+		log.Warn("code issued to another client or redirect uri")
+		return nil, ErrInvalidGrant
+	}
+
+	if !verifyCodeChallenge(req.CodeVerifier, authCode.CodeChallenge) {
+		log.Warn("code verifier mismatch")
+		return nil, ErrInvalidGrant
+	}
+
+	user, err := a.userStorage.Find(ctx, authCode.UserId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return nil, ErrInvalidGrant
+	}
+
+	tokens, err := a.startClientSession(ctx, user, &req.Device, "", client.Id, authCode.Scope)
+	if err != nil {
+		log.Error("start session error", sl.Err(err))
+		return nil, err
+	}
+
+	idToken, err := a.signIdToken(user, authCode)
+	if err != nil {
+		log.Error("sign id token error", sl.Err(err))
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		IdToken:      idToken,
+		ExpiresIn:    a.cfg.Jwt.AccessTTL * 60,
+		Scope:        strings.Join(authCode.Scope, " "),
+	}, nil
+}
+
+// refreshGrant rotates a refresh token issued to the authenticated client.
+func (a *AuthService) refreshGrant(ctx context.Context, req *dto.Token) (*dto.TokenResponse, error) {
+
+	log := a.logger.With(slog.String("method", "refreshGrant"), slog.String("clientId", req.ClientId))
+
+	client, err := a.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		log.Warn("client authentication failed", sl.Err(err))
+		return nil, err
+	}
+
+	if !lo.Contains(client.GrantTypes, GrantRefreshToken) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	tokens, err := a.rotate(ctx, &dto.Refresh{
+		RefreshToken: req.RefreshToken,
+		Device:       req.Device,
+	}, func(claims *entity.UserClaims, session *entity.Session) error {
+		if session.ClientId != client.Id {
+			log.Warn("refresh token issued to another client", slog.String("sessionClientId", session.ClientId))
+			return ErrTokenInvalid
+		}
+
+		return a.checkOrganization(ctx, claims)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrTokenReused) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    a.cfg.Jwt.AccessTTL * 60,
+	}, nil
+}
+
+// clientCredentials issues a machine token whose subject is the client itself.
+func (a *AuthService) clientCredentials(ctx context.Context, req *dto.Token) (*dto.TokenResponse, error) {
+
+	log := a.logger.With(slog.String("method", "clientCredentials"), slog.String("clientId", req.ClientId))
+
+	client, err := a.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		log.Warn("client authentication failed", sl.Err(err))
+		return nil, err
+	}
+
+	if !client.Confidential() || !lo.Contains(client.GrantTypes, GrantClientCredentials) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	scope := strings.Fields(req.Scope)
+	if len(scope) == 0 {
+		scope = client.Scopes
+	}
+
+	if !lo.Every(client.Scopes, scope) {
+		return nil, ErrInvalidScope
+	}
+
+	key, err := a.keyring.Active()
+	if err != nil {
+		log.Error("no active signing key", sl.Err(err))
+		return nil, err
+	}
+
+	accessToken, err := jwt.Sign(&entity.UserClaims{
+		Id:       client.Id,
+		ClientId: client.Id,
+		Scope:    scope,
+	}, time.Duration(a.cfg.Jwt.AccessTTL)*time.Minute, key, jwt.Options{
+		Issuer:   a.issuer(),
+		Audience: a.audiences()[:1],
+	})
+	if err != nil {
+		log.Error("sign access token error", sl.Err(err))
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   a.cfg.Jwt.AccessTTL * 60,
+		Scope:       strings.Join(scope, " "),
+	}, nil
+}
+
+// signIdToken signs with an asymmetric key, relying parties verify ID tokens through the JWKS
+// and must never be handed the shared secret.
+func (a *AuthService) signIdToken(user *entity.User, authCode *entity.AuthCode) (string, error) {
+	key, err := a.keyring.ActiveAsymmetric()
+	if err != nil {
+		return "", err
+	}
+
+	id := &jwt.IdToken{
+		Subject:  user.Id,
+		Nonce:    authCode.Nonce,
+		AuthTime: authCode.AuthTime,
+	}
+
+	if lo.Contains(authCode.Scope, ScopeEmail) {
+		verified := user.EmailVerifiedAt != nil
+		id.Email = user.Email
+		id.EmailVerified = &verified
+	}
+
+	if lo.Contains(authCode.Scope, ScopeProfile) {
+		id.GivenName = user.FirstName
+		id.FamilyName = user.LastName
+		id.MiddleName = user.MiddleName
+	}
+
+	return jwt.SignIdToken(id, time.Duration(a.cfg.Jwt.AccessTTL)*time.Minute, key, jwt.Options{
+		Issuer:   a.issuer(),
+		Audience: []string{authCode.ClientId},
+	})
+}
+
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if verifier == "" {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
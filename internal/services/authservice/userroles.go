@@ -0,0 +1,163 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+
+	"github.com/samber/lo"
+)
+
+// UserRoles returns the global roles of the user.
+func (a *AuthService) UserRoles(ctx context.Context, userId string) ([]entity.Role, error) {
+
+	log := a.logger.With(slog.String("method", "UserRoles"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return nil, err
+	}
+
+	roles, err := a.roleStorage.ListUser(ctx, user.Id, "")
+	if err != nil {
+		log.Error("list user roles error", sl.Err(err))
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GrantRoles adds roles to the user, every change is recorded in the role audit.
+// Roles in an organization can be granted to its members by the organization admins.
+func (a *AuthService) GrantRoles(ctx context.Context, req *dto.AddRoles) ([]entity.Role, error) {
+
+	log := a.logger.With(slog.String("method", "GrantRoles"), slog.String("userId", req.UserId), slog.String("actorId", req.ActorId))
+
+	if len(req.Roles) == 0 {
+		return nil, fmt.Errorf("%w: roles are required", ErrInvalidRequest)
+	}
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return nil, err
+	}
+	req.UserId = user.Id
+
+	if req.OrganizationId != "" {
+		organization, err := a.organizationAdmin(ctx, req.OrganizationId, req.ActorId)
+		if err != nil {
+			log.Warn("organization access denied", sl.Err(err))
+			return nil, err
+		}
+		req.OrganizationId = organization.Id
+
+		if err := a.requireMember(ctx, organization.Id, user.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.roleStorage.Add(ctx, req); err != nil {
+		log.Error("add roles error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	log.Info("roles granted", slog.Any("roles", req.Roles))
+
+	return a.roleStorage.ListUser(ctx, req.UserId, req.OrganizationId)
+}
+
+// RevokeRoles removes roles from the user, every change is recorded in the role audit.
+// Admins cannot take the admin role from themselves.
+// Roles in an organization can be revoked by the organization admins.
+func (a *AuthService) RevokeRoles(ctx context.Context, req *dto.RemoveRoles) ([]entity.Role, error) {
+
+	log := a.logger.With(slog.String("method", "RevokeRoles"), slog.String("userId", req.UserId), slog.String("actorId", req.ActorId))
+
+	if len(req.Roles) == 0 {
+		return nil, fmt.Errorf("%w: roles are required", ErrInvalidRequest)
+	}
+
+	user, err := a.userStorage.Find(ctx, req.UserId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return nil, err
+	}
+	req.UserId = user.Id
+
+	if req.OrganizationId != "" {
+		organization, err := a.organizationAdmin(ctx, req.OrganizationId, req.ActorId)
+		if err != nil {
+			log.Warn("organization access denied", sl.Err(err))
+			return nil, err
+		}
+		req.OrganizationId = organization.Id
+
+		if err := a.requireMember(ctx, organization.Id, user.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.ActorId == req.UserId && lo.Contains(req.Roles, entity.RoleAdmin) {
+		return nil, fmt.Errorf("%w: cannot revoke own %s role", ErrInvalidRequest, entity.RoleAdmin)
+	}
+
+	if err := a.roleStorage.Remove(ctx, req); err != nil {
+		log.Error("remove roles error", sl.Err(err))
+		return nil, roleError(err)
+	}
+
+	// access tokens still carry the revoked roles, make the user refresh them
+	if err := a.revocations.RevokeBefore(ctx, req.UserId, time.Now()); err != nil {
+		log.Error("revoke access tokens error", sl.Err(err))
+		return nil, err
+	}
+
+	log.Info("roles revoked", slog.Any("roles", req.Roles))
+
+	return a.roleStorage.ListUser(ctx, req.UserId, req.OrganizationId)
+}
+
+func (a *AuthService) RoleAudit(ctx context.Context, userId string) ([]entity.RoleAudit, error) {
+
+	log := a.logger.With(slog.String("method", "RoleAudit"), slog.String("userId", userId))
+
+	user, err := a.userStorage.Find(ctx, userId)
+	if err != nil {
+		log.Warn("user not found", sl.Err(err))
+		return nil, err
+	}
+
+	records, err := a.roleStorage.Audit(ctx, user.Id)
+	if err != nil {
+		log.Error("list role audit error", sl.Err(err))
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// EffectiveRoles returns the global roles of the user together with the roles held in the organization.
+func (a *AuthService) EffectiveRoles(ctx context.Context, userId, organizationId string) ([]entity.Role, error) {
+	roles, err := a.roleStorage.ListUser(ctx, userId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if organizationId == "" {
+		return roles, nil
+	}
+
+	scoped, err := a.roleStorage.ListUser(ctx, userId, organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Union(roles, scoped), nil
+}
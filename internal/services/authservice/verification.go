@@ -0,0 +1,116 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+)
+
+// VerifyEmail marks the email of the token owner as verified.
+// The token is rejected if the user changed the email after it was issued.
+func (a *AuthService) VerifyEmail(ctx context.Context, token string) error {
+
+	log := a.logger.With(slog.String("method", "VerifyEmail"))
+
+	claims, err := a.consumeActionToken(ctx, purposeVerifyEmail, token)
+	if err != nil {
+		log.Warn("verification token rejected", sl.Err(err))
+		return err
+	}
+
+	log = log.With(slog.String("userId", claims.Id))
+
+	user, err := a.userStorage.Find(ctx, claims.Id)
+	if err != nil {
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if !strings.EqualFold(user.Email, claims.Email) {
+		log.Warn("email changed since the token was issued")
+		return fmt.Errorf("%w: email changed", ErrTokenInvalid)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if _, err := a.userStorage.Update(ctx, user.Id, &dto.UpdateUser{EmailVerifiedAt: &now}); err != nil {
+		log.Error("update user error", sl.Err(err))
+		return err
+	}
+
+	log.Info("email verified")
+
+	return nil
+}
+
+// ResendVerification sends a new verification mail.
+// Unknown, disabled and already verified emails are silently ignored, so the result does not reveal accounts.
+func (a *AuthService) ResendVerification(ctx context.Context, email string) error {
+
+	log := a.logger.With(slog.String("method", "ResendVerification"))
+
+	user, err := a.userStorage.Find(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrUserDisabled) {
+			log.Debug("user not found")
+			return nil
+		}
+		log.Error("find user error", sl.Err(err))
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		log.Debug("email already verified", slog.String("userId", user.Id))
+		return nil
+	}
+
+	return a.sendVerification(ctx, user)
+}
+
+func (a *AuthService) sendVerification(ctx context.Context, user *entity.User) error {
+	ttl := time.Duration(a.cfg.Registration.VerificationTTL) * time.Minute
+
+	token, err := a.issueActionToken(ctx, purposeVerifyEmail, user.Id, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(a.cfg.Registration.VerificationUrl, token)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, &dto.Mail{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Confirm your email address by following the link:\n\n%s\n\nThe link expires in %s. If you did not sign up, ignore this message.\n",
+			link, ttl,
+		),
+	})
+}
+
+// withToken appends the token to the link as a query parameter.
+func withToken(link, token string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
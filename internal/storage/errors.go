@@ -6,4 +6,21 @@ var (
 	ErrUserNotFound           = errors.New("user not found")
 	ErrUserAlreadyExists      = errors.New("user already exists")
 	ErrInsufficentPermissions = errors.New("insufficent permsissions")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrSessionInvalid         = errors.New("session invalid")
+	ErrSessionReused          = errors.New("session token reused")
+	ErrAuthCodeNotFound       = errors.New("authorization code not found")
+	ErrClientNotFound         = errors.New("client not found")
+	ErrOrganizationNotFound   = errors.New("organization not found")
+	ErrOrganizationExists     = errors.New("organization already exists")
+	ErrMemberNotFound         = errors.New("member not found")
+	ErrPasskeyNotFound        = errors.New("passkey not found")
+	ErrPasskeyExists          = errors.New("passkey already exists")
+	ErrTokenUsed              = errors.New("token already used")
+	ErrTokenNotFound          = errors.New("token not found")
+	ErrInviteNotFound         = errors.New("invite not found")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleAlreadyExists      = errors.New("role already exists")
+	ErrPermissionNotFound     = errors.New("permission not found")
+	ErrPermissionExists       = errors.New("permission already exists")
 )
@@ -0,0 +1,176 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ authservice.ClientStorage = (*ClientStorage)(nil)
+
+type ClientStorage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+// clientRow stores list fields space separated, the same way OAuth encodes scopes.
+type clientRow struct {
+	Id           string     `db:"id"`
+	Name         string     `db:"name"`
+	HashedSecret *string    `db:"hashed_secret"`
+	RedirectUris string     `db:"redirect_uris"`
+	Scopes       string     `db:"scopes"`
+	GrantTypes   string     `db:"grant_types"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    *time.Time `db:"updated_at"`
+}
+
+func (r *clientRow) entity() *entity.Client {
+	return &entity.Client{
+		Id:           r.Id,
+		Name:         r.Name,
+		HashedSecret: r.HashedSecret,
+		RedirectUris: strings.Fields(r.RedirectUris),
+		Scopes:       strings.Fields(r.Scopes),
+		GrantTypes:   strings.Fields(r.GrantTypes),
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+func NewClientStorage(db *sqlx.DB) *ClientStorage {
+	return &ClientStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "ClientStorage")),
+	}
+}
+
+func (s *ClientStorage) Find(ctx context.Context, clientId string) (*entity.Client, error) {
+	log := s.logger.With(slog.String("method", "Find"), slog.String("client_id", clientId))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(clientsTable).
+		Where(squirrel.Eq{"id": clientId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	row := new(clientRow)
+	if err := s.db.GetContext(ctx, row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrClientNotFound
+		}
+		log.Error("error finding client", sl.Err(err))
+		return nil, err
+	}
+
+	return row.entity(), nil
+}
+
+func (s *ClientStorage) List(ctx context.Context) ([]entity.Client, error) {
+	log := s.logger.With(slog.String("method", "List"))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(clientsTable).
+		OrderBy("created_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	rows := make([]clientRow, 0)
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		log.Error("error listing clients", sl.Err(err))
+		return nil, err
+	}
+
+	clients := make([]entity.Client, 0, len(rows))
+	for _, row := range rows {
+		clients = append(clients, *row.entity())
+	}
+
+	return clients, nil
+}
+
+func (s *ClientStorage) Save(ctx context.Context, client *entity.Client) (*entity.Client, error) {
+	log := s.logger.With(slog.String("method", "Save"), slog.String("client_id", client.Id))
+
+	query, args, err := squirrel.
+		Insert(clientsTable).
+		Columns("id", "name", "hashed_secret", "redirect_uris", "scopes", "grant_types").
+		Values(
+			client.Id,
+			client.Name,
+			client.HashedSecret,
+			strings.Join(client.RedirectUris, " "),
+			strings.Join(client.Scopes, " "),
+			strings.Join(client.GrantTypes, " "),
+		).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	row := new(clientRow)
+	if err := s.db.GetContext(ctx, row, query, args...); err != nil {
+		log.Error("error saving client", sl.Err(err))
+		return nil, err
+	}
+
+	return row.entity(), nil
+}
+
+func (s *ClientStorage) Delete(ctx context.Context, clientId string) error {
+	log := s.logger.With(slog.String("method", "Delete"), slog.String("client_id", clientId))
+
+	query, args, err := squirrel.
+		Delete(clientsTable).
+		Where(squirrel.Eq{"id": clientId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error deleting client", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrClientNotFound
+	}
+
+	return nil
+}
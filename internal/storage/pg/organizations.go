@@ -0,0 +1,263 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ authservice.OrganizationStorage = (*OrganizationStorage)(nil)
+
+type OrganizationStorage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewOrganizationStorage(db *sqlx.DB) *OrganizationStorage {
+	return &OrganizationStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "OrganizationStorage")),
+	}
+}
+
+// Find looks the organization up by id or slug.
+func (s *OrganizationStorage) Find(ctx context.Context, slug string) (*entity.Organization, error) {
+	log := s.logger.With(slog.String("method", "Find"), slog.String("organization", slug))
+
+	builder := squirrel.
+		Select("*").
+		From(organizationsTable).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if _, err := uuid.Parse(slug); err != nil {
+		builder = builder.Where(squirrel.Eq{"slug": slug})
+	} else {
+		builder = builder.Where(squirrel.Eq{"id": slug})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	organization := new(entity.Organization)
+	if err := s.db.GetContext(ctx, organization, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrOrganizationNotFound
+		}
+		log.Error("error finding organization", sl.Err(err))
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+// List returns the organizations the user is a member of.
+func (s *OrganizationStorage) List(ctx context.Context, userId string) ([]entity.Organization, error) {
+	log := s.logger.With(slog.String("method", "List"), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Select("o.*").
+		From(organizationsTable + " o").
+		Join(membersTable + " m ON m.organization_id = o.id").
+		Where(squirrel.Eq{"m.user_id": userId}).
+		OrderBy("o.name").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	organizations := make([]entity.Organization, 0)
+	if err := s.db.SelectContext(ctx, &organizations, query, args...); err != nil {
+		log.Error("error listing organizations", sl.Err(err))
+		return nil, err
+	}
+
+	return organizations, nil
+}
+
+// Save creates the organization with its owner as the first member.
+func (s *OrganizationStorage) Save(ctx context.Context, organization *dto.CreateOrganization) (result *entity.Organization, err error) {
+	log := s.logger.With(slog.String("method", "Save"), slog.String("slug", organization.Slug))
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Error("cannot begin transaction", sl.Err(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			log.Error("cannot commit transaction", sl.Err(err))
+			result = nil
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert(organizationsTable).
+		Columns("name", "slug").
+		Values(organization.Name, organization.Slug).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	result = new(entity.Organization)
+	if err = tx.GetContext(ctx, result, query, args...); err != nil {
+		if e, ok := err.(pgx.PgError); ok && e.Code == "23505" {
+			return nil, storage.ErrOrganizationExists
+		}
+		log.Error("error saving organization", sl.Err(err))
+		return nil, err
+	}
+
+	query, args, err = squirrel.
+		Insert(membersTable).
+		Columns("organization_id", "user_id").
+		Values(result.Id, organization.OwnerId).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		log.Error("error saving owner", sl.Err(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *OrganizationStorage) Members(ctx context.Context, organizationId string) ([]entity.Member, error) {
+	log := s.logger.With(slog.String("method", "Members"), slog.String("organization_id", organizationId))
+
+	query, args, err := squirrel.
+		Select("m.user_id", "u.email", "m.created_at").
+		From(membersTable + " m").
+		Join(usersTable + " u ON u.id = m.user_id").
+		Where(squirrel.Eq{"m.organization_id": organizationId}).
+		OrderBy("m.created_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	members := make([]entity.Member, 0)
+	if err := s.db.SelectContext(ctx, &members, query, args...); err != nil {
+		log.Error("error listing members", sl.Err(err))
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *OrganizationStorage) IsMember(ctx context.Context, organizationId, userId string) (bool, error) {
+	log := s.logger.With(slog.String("method", "IsMember"), slog.String("organization_id", organizationId), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From(membersTable).
+		Where(squirrel.Eq{"organization_id": organizationId, "user_id": userId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return false, err
+	}
+
+	var count int
+	if err := s.db.GetContext(ctx, &count, query, args...); err != nil {
+		log.Error("error checking membership", sl.Err(err))
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *OrganizationStorage) AddMember(ctx context.Context, organizationId, userId string) error {
+	log := s.logger.With(slog.String("method", "AddMember"), slog.String("organization_id", organizationId), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Insert(membersTable).
+		Columns("organization_id", "user_id").
+		Values(organizationId, userId).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		log.Error("error adding member", sl.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *OrganizationStorage) RemoveMember(ctx context.Context, organizationId, userId string) error {
+	log := s.logger.With(slog.String("method", "RemoveMember"), slog.String("organization_id", organizationId), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Delete(membersTable).
+		Where(squirrel.Eq{"organization_id": organizationId, "user_id": userId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error removing member", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrMemberNotFound
+	}
+
+	return nil
+}
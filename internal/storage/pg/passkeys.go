@@ -0,0 +1,174 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ authservice.PasskeyStorage = (*PasskeyStorage)(nil)
+
+type PasskeyStorage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPasskeyStorage(db *sqlx.DB) *PasskeyStorage {
+	return &PasskeyStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "PasskeyStorage")),
+	}
+}
+
+func (s *PasskeyStorage) Find(ctx context.Context, id []byte) (*entity.Passkey, error) {
+	log := s.logger.With(slog.String("method", "Find"))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(passkeysTable).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	passkey := new(entity.Passkey)
+	if err := s.db.GetContext(ctx, passkey, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrPasskeyNotFound
+		}
+		log.Error("error finding passkey", sl.Err(err))
+		return nil, err
+	}
+
+	return passkey, nil
+}
+
+func (s *PasskeyStorage) List(ctx context.Context, userId string) ([]entity.Passkey, error) {
+	log := s.logger.With(slog.String("method", "List"), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(passkeysTable).
+		Where(squirrel.Eq{"user_id": userId}).
+		OrderBy("created_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	passkeys := make([]entity.Passkey, 0)
+	if err := s.db.SelectContext(ctx, &passkeys, query, args...); err != nil {
+		log.Error("error listing passkeys", sl.Err(err))
+		return nil, err
+	}
+
+	return passkeys, nil
+}
+
+func (s *PasskeyStorage) Save(ctx context.Context, passkey *dto.CreatePasskey) (*entity.Passkey, error) {
+	log := s.logger.With(slog.String("method", "Save"), slog.String("user_id", passkey.UserId))
+
+	query, args, err := squirrel.
+		Insert(passkeysTable).
+		Columns("id", "user_id", "public_key", "sign_count", "name").
+		Values(passkey.Id, passkey.UserId, passkey.PublicKey, passkey.SignCount, passkey.Name).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	result := new(entity.Passkey)
+	if err := s.db.GetContext(ctx, result, query, args...); err != nil {
+		if e, ok := err.(pgx.PgError); ok && e.Code == "23505" {
+			return nil, storage.ErrPasskeyExists
+		}
+		log.Error("error saving passkey", sl.Err(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Use stores the new signature counter, it fails if another login advanced the counter first.
+func (s *PasskeyStorage) Use(ctx context.Context, id []byte, previous, signCount int64) error {
+	log := s.logger.With(slog.String("method", "Use"))
+
+	query, args, err := squirrel.
+		Update(passkeysTable).
+		Set("sign_count", signCount).
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "sign_count": previous}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error updating passkey", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func (s *PasskeyStorage) Delete(ctx context.Context, userId string, id []byte) error {
+	log := s.logger.With(slog.String("method", "Delete"), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Delete(passkeysTable).
+		Where(squirrel.Eq{"id": id, "user_id": userId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error deleting passkey", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrPasskeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package pg
+
+import (
+	"context"
+	"log/slog"
+
+	"mzhn/auth/internal/dto"
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ authservice.PermissionStorage = (*PermissionStorage)(nil)
+
+type PermissionStorage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPermissionStorage(db *sqlx.DB) *PermissionStorage {
+	return &PermissionStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "PermissionStorage")),
+	}
+}
+
+func (s *PermissionStorage) List(ctx context.Context) ([]entity.PermissionDefinition, error) {
+	log := s.logger.With(slog.String("method", "List"))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(permissionsTable).
+		OrderBy("name").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	permissions := make([]entity.PermissionDefinition, 0)
+	if err := s.db.SelectContext(ctx, &permissions, query, args...); err != nil {
+		log.Error("error listing permissions", sl.Err(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (s *PermissionStorage) Save(ctx context.Context, permission *dto.CreatePermission) (*entity.PermissionDefinition, error) {
+	log := s.logger.With(slog.String("method", "Save"), slog.String("permission", permission.Name.String()))
+
+	query, args, err := squirrel.
+		Insert(permissionsTable).
+		Columns("name", "description").
+		Values(permission.Name, permission.Description).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	result := new(entity.PermissionDefinition)
+	if err := s.db.GetContext(ctx, result, query, args...); err != nil {
+		if e, ok := err.(pgx.PgError); ok && e.Code == "23505" {
+			return nil, storage.ErrPermissionExists
+		}
+		log.Error("error saving permission", sl.Err(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *PermissionStorage) Delete(ctx context.Context, name entity.Permission) error {
+	log := s.logger.With(slog.String("method", "Delete"), slog.String("permission", name.String()))
+
+	query, args, err := squirrel.
+		Delete(permissionsTable).
+		Where(squirrel.Eq{"name": name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error deleting permission", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrPermissionNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package pg
+
+import (
+	"context"
+	"log/slog"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ authservice.RecoveryCodeStorage = (*RecoveryCodeStorage)(nil)
+
+type RecoveryCodeStorage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewRecoveryCodeStorage(db *sqlx.DB) *RecoveryCodeStorage {
+	return &RecoveryCodeStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "RecoveryCodeStorage")),
+	}
+}
+
+// Replace drops every code of the user and stores the new hashes in their place.
+func (s *RecoveryCodeStorage) Replace(ctx context.Context, userId string, hashes []string) (err error) {
+	log := s.logger.With(slog.String("method", "Replace"), slog.String("user_id", userId))
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Error("cannot begin transaction", sl.Err(err))
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			log.Error("cannot commit transaction", sl.Err(err))
+		}
+	}()
+
+	query, args, err := squirrel.
+		Delete(recoveryCodesTable).
+		Where(squirrel.Eq{"user_id": userId}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		log.Error("error deleting recovery codes", sl.Err(err))
+		return err
+	}
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	builder := squirrel.
+		Insert(recoveryCodesTable).
+		Columns("user_id", "code_hash").
+		PlaceholderFormat(squirrel.Dollar)
+
+	for _, hash := range hashes {
+		builder = builder.Values(userId, hash)
+	}
+
+	query, args, err = builder.ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		log.Error("error saving recovery codes", sl.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// ListUnused returns the codes of the user that have not been used yet.
+func (s *RecoveryCodeStorage) ListUnused(ctx context.Context, userId string) ([]entity.RecoveryCode, error) {
+	log := s.logger.With(slog.String("method", "ListUnused"), slog.String("user_id", userId))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(recoveryCodesTable).
+		Where(squirrel.Eq{"user_id": userId, "used_at": nil}).
+		OrderBy("id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	codes := make([]entity.RecoveryCode, 0)
+	if err := s.db.SelectContext(ctx, &codes, query, args...); err != nil {
+		log.Error("error listing recovery codes", sl.Err(err))
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Use marks the code as used, it reports false when another request used it first.
+func (s *RecoveryCodeStorage) Use(ctx context.Context, id int64) (bool, error) {
+	log := s.logger.With(slog.String("method", "Use"), slog.Int64("id", id))
+
+	query, args, err := squirrel.
+		Update(recoveryCodesTable).
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return false, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error using recovery code", sl.Err(err))
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
@@ -2,14 +2,18 @@ package pg
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
 
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
 	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx"
 	"github.com/jmoiron/sqlx"
 	"github.com/samber/lo"
 )
@@ -54,16 +58,11 @@ func (r *RoleStorage) Add(ctx context.Context, dto *dto.AddRoles) (err error) {
 	}()
 
 	for _, role := range dto.Roles {
-
-		if !role.Valid() {
-			log.Warn("invalid role", slog.String("role", role.String()))
-			continue
-		}
-
 		query, args, err := squirrel.
-			Insert(roleTable).
-			Columns("user_id", "role").
-			Values(dto.UserId, role).
+			Insert(userRolesTable).
+			Columns("user_id", "role", "organization_id").
+			Values(dto.UserId, role, nullString(dto.OrganizationId)).
+			Suffix("ON CONFLICT DO NOTHING").
 			PlaceholderFormat(squirrel.Dollar).
 			ToSql()
 		if err != nil {
@@ -75,8 +74,21 @@ func (r *RoleStorage) Add(ctx context.Context, dto *dto.AddRoles) (err error) {
 
 		qlog.Debug("executing query")
 
-		if _, err := tx.Exec(query, args...); err != nil {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
 			qlog.Error("cannot execute query", sl.Err(err))
+			if e, ok := err.(pgx.PgError); ok && e.Code == "23503" {
+				return storage.ErrRoleNotFound
+			}
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		if err := r.audit(tx, dto.UserId, dto.ActorId, dto.OrganizationId, entity.RoleAuditGrant, role); err != nil {
+			log.Error("cannot write audit", sl.Err(err))
 			return err
 		}
 	}
@@ -84,15 +96,17 @@ func (r *RoleStorage) Add(ctx context.Context, dto *dto.AddRoles) (err error) {
 	return nil
 }
 
-func (r *RoleStorage) ListUser(ctx context.Context, userId string) ([]entity.Role, error) {
+// ListUser returns roles assigned to the user in exactly the organization, an empty id lists global roles.
+func (r *RoleStorage) ListUser(ctx context.Context, userId, organizationId string) ([]entity.Role, error) {
 	log := r.logger.With(slog.String("method", "ListUser"))
 
-	log.Debug("listing user's roles", slog.String("userId", userId))
+	log.Debug("listing user's roles", slog.String("userId", userId), slog.String("organizationId", organizationId))
 
 	query, args, err := squirrel.
 		Select("role").
-		From(roleTable).
+		From(userRolesTable).
 		Where(squirrel.Eq{"user_id": userId}).
+		Where(scopeEq("organization_id", organizationId)).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
@@ -126,6 +140,7 @@ func (r *RoleStorage) ListUser(ctx context.Context, userId string) ([]entity.Rol
 	return roles, nil
 }
 
+// Check reports whether the user has one of the roles globally or in the organization.
 func (r *RoleStorage) Check(ctx context.Context, dto *dto.CheckRoles) (bool, error) {
 	log := r.logger.With(slog.String("method", "Check"))
 
@@ -137,8 +152,9 @@ func (r *RoleStorage) Check(ctx context.Context, dto *dto.CheckRoles) (bool, err
 
 	query, args, err := squirrel.
 		Select("role").
-		From(roleTable).
+		From(userRolesTable).
 		Where(squirrel.Eq{"user_id": dto.UserId}).
+		Where(effectiveScope("organization_id", dto.OrganizationId)).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
@@ -197,8 +213,9 @@ func (r *RoleStorage) Remove(ctx context.Context, dto *dto.RemoveRoles) (err err
 
 	for _, role := range dto.Roles {
 		query, args, err := squirrel.
-			Delete(roleTable).
+			Delete(userRolesTable).
 			Where(squirrel.Eq{"user_id": dto.UserId, "role": role}).
+			Where(scopeEq("organization_id", dto.OrganizationId)).
 			PlaceholderFormat(squirrel.Dollar).
 			ToSql()
 		if err != nil {
@@ -210,12 +227,448 @@ func (r *RoleStorage) Remove(ctx context.Context, dto *dto.RemoveRoles) (err err
 
 		qlog.Debug("executing query")
 
-		if _, err := tx.Exec(query, args...); err != nil {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
 			qlog.Error("cannot execute query", sl.Err(err))
 			return err
 		}
 
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		if err := r.audit(tx, dto.UserId, dto.ActorId, dto.OrganizationId, entity.RoleAuditRevoke, role); err != nil {
+			log.Error("cannot write audit", sl.Err(err))
+			return err
+		}
 	}
 
 	return nil
 }
+
+// CheckPermissions reports whether the global and organization roles of the user grant every one of the permissions.
+func (r *RoleStorage) CheckPermissions(ctx context.Context, dto *dto.CheckPermissions) (bool, error) {
+	log := r.logger.With(slog.String("method", "CheckPermissions"))
+
+	permissions := lo.Uniq(dto.Permissions)
+	if len(permissions) == 0 {
+		return true, nil
+	}
+
+	log.Debug("dto", slog.Any("dto", dto))
+
+	query, args, err := squirrel.
+		Select("COUNT(DISTINCT rp.permission)").
+		From(userRolesTable + " ur").
+		Join(rolePermissionsTable + " rp ON rp.role = ur.role").
+		Where(squirrel.Eq{"ur.user_id": dto.UserId, "rp.permission": permissions}).
+		Where(effectiveScope("ur.organization_id", dto.OrganizationId)).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return false, err
+	}
+
+	qlog := log.With(slog.String("query", query), slog.Any("args", args))
+	qlog.Debug("executing query")
+
+	var granted int
+	if err := r.db.GetContext(ctx, &granted, query, args...); err != nil {
+		qlog.Error("cannot execute query", sl.Err(err))
+		return false, err
+	}
+
+	return granted == len(permissions), nil
+}
+
+// ListUserPermissions returns permissions granted by the global and organization roles of the user.
+func (r *RoleStorage) ListUserPermissions(ctx context.Context, userId, organizationId string) ([]entity.Permission, error) {
+	log := r.logger.With(slog.String("method", "ListUserPermissions"))
+
+	log.Debug("listing user's permissions", slog.String("userId", userId), slog.String("organizationId", organizationId))
+
+	query, args, err := squirrel.
+		Select("DISTINCT rp.permission").
+		From(userRolesTable + " ur").
+		Join(rolePermissionsTable + " rp ON rp.role = ur.role").
+		Where(squirrel.Eq{"ur.user_id": userId}).
+		Where(effectiveScope("ur.organization_id", organizationId)).
+		OrderBy("rp.permission").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	qlog := log.With(slog.String("query", query), slog.Any("args", args))
+	qlog.Debug("executing query")
+
+	permissions := make([]entity.Permission, 0)
+	if err := r.db.SelectContext(ctx, &permissions, query, args...); err != nil {
+		qlog.Error("cannot execute query", sl.Err(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleStorage) Find(ctx context.Context, name entity.Role) (*entity.RoleDefinition, error) {
+	log := r.logger.With(slog.String("method", "Find"), slog.String("role", name.String()))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(rolesTable).
+		Where(squirrel.Eq{"name": name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	role := new(entity.RoleDefinition)
+	if err := r.db.GetContext(ctx, role, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRoleNotFound
+		}
+		log.Error("error finding role", sl.Err(err))
+		return nil, err
+	}
+
+	permissions, err := r.rolePermissions(ctx, r.db, name)
+	if err != nil {
+		log.Error("error listing role permissions", sl.Err(err))
+		return nil, err
+	}
+	role.Permissions = permissions[name]
+
+	return role, nil
+}
+
+func (r *RoleStorage) List(ctx context.Context) ([]entity.RoleDefinition, error) {
+	log := r.logger.With(slog.String("method", "List"))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(rolesTable).
+		OrderBy("name").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	roles := make([]entity.RoleDefinition, 0)
+	if err := r.db.SelectContext(ctx, &roles, query, args...); err != nil {
+		log.Error("error listing roles", sl.Err(err))
+		return nil, err
+	}
+
+	permissions, err := r.rolePermissions(ctx, r.db, "")
+	if err != nil {
+		log.Error("error listing role permissions", sl.Err(err))
+		return nil, err
+	}
+
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].Name]
+	}
+
+	return roles, nil
+}
+
+func (r *RoleStorage) Save(ctx context.Context, role *dto.CreateRole) (result *entity.RoleDefinition, err error) {
+	log := r.logger.With(slog.String("method", "Save"), slog.String("role", role.Name.String()))
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Error("cannot begin transaction", sl.Err(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			log.Error("cannot commit transaction", sl.Err(err))
+			result = nil
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert(rolesTable).
+		Columns("name", "description").
+		Values(role.Name, role.Description).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	result = new(entity.RoleDefinition)
+	if err = tx.GetContext(ctx, result, query, args...); err != nil {
+		if e, ok := err.(pgx.PgError); ok && e.Code == "23505" {
+			return nil, storage.ErrRoleAlreadyExists
+		}
+		log.Error("error saving role", sl.Err(err))
+		return nil, err
+	}
+
+	if err = r.setPermissions(ctx, tx, role.Name, role.Permissions); err != nil {
+		log.Error("error saving role permissions", sl.Err(err))
+		return nil, err
+	}
+	result.Permissions = lo.Uniq(role.Permissions)
+
+	return result, nil
+}
+
+func (r *RoleStorage) Update(ctx context.Context, role *dto.UpdateRole) (result *entity.RoleDefinition, err error) {
+	log := r.logger.With(slog.String("method", "Update"), slog.String("role", role.Name.String()))
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Error("cannot begin transaction", sl.Err(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			log.Error("cannot commit transaction", sl.Err(err))
+			result = nil
+		}
+	}()
+
+	builder := squirrel.
+		Update(rolesTable).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"name": role.Name}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if role.Description != nil {
+		builder = builder.Set("description", role.Description)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	result = new(entity.RoleDefinition)
+	if err = tx.GetContext(ctx, result, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRoleNotFound
+		}
+		log.Error("error updating role", sl.Err(err))
+		return nil, err
+	}
+
+	if role.Permissions != nil {
+		if err = r.setPermissions(ctx, tx, role.Name, role.Permissions); err != nil {
+			log.Error("error saving role permissions", sl.Err(err))
+			return nil, err
+		}
+	}
+
+	permissions, err := r.rolePermissions(ctx, tx, role.Name)
+	if err != nil {
+		log.Error("error listing role permissions", sl.Err(err))
+		return nil, err
+	}
+	result.Permissions = permissions[role.Name]
+
+	return result, nil
+}
+
+func (r *RoleStorage) Delete(ctx context.Context, name entity.Role) error {
+	log := r.logger.With(slog.String("method", "Delete"), slog.String("role", name.String()))
+
+	query, args, err := squirrel.
+		Delete(rolesTable).
+		Where(squirrel.Eq{"name": name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error deleting role", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrRoleNotFound
+	}
+
+	return nil
+}
+
+// setPermissions replaces the permissions granted by the role.
+func (r *RoleStorage) setPermissions(ctx context.Context, tx *sqlx.Tx, name entity.Role, permissions []entity.Permission) error {
+	query, args, err := squirrel.
+		Delete(rolePermissionsTable).
+		Where(squirrel.Eq{"role": name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	permissions = lo.Uniq(permissions)
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	builder := squirrel.
+		Insert(rolePermissionsTable).
+		Columns("role", "permission").
+		PlaceholderFormat(squirrel.Dollar)
+
+	for _, permission := range permissions {
+		builder = builder.Values(name, permission)
+	}
+
+	query, args, err = builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		if e, ok := err.(pgx.PgError); ok && e.Code == "23503" {
+			return storage.ErrPermissionNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// rolePermissions groups granted permissions by role, an empty name loads every role.
+func (r *RoleStorage) rolePermissions(ctx context.Context, db sqlx.QueryerContext, name entity.Role) (map[entity.Role][]entity.Permission, error) {
+	builder := squirrel.
+		Select("role", "permission").
+		From(rolePermissionsTable).
+		OrderBy("role", "permission").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if name != "" {
+		builder = builder.Where(squirrel.Eq{"role": name})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]struct {
+		Role       entity.Role       `db:"role"`
+		Permission entity.Permission `db:"permission"`
+	}, 0)
+	if err := sqlx.SelectContext(ctx, db, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[entity.Role][]entity.Permission)
+	for _, row := range rows {
+		permissions[row.Role] = append(permissions[row.Role], row.Permission)
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleStorage) Audit(ctx context.Context, userId string) ([]entity.RoleAudit, error) {
+	log := r.logger.With(slog.String("method", "Audit"), slog.String("userId", userId))
+
+	query, args, err := squirrel.
+		Select("*").
+		From(roleAuditTable).
+		Where(squirrel.Eq{"user_id": userId}).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("cannot build query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	records := make([]entity.RoleAudit, 0)
+	if err := r.db.SelectContext(ctx, &records, query, args...); err != nil {
+		log.Error("error listing role audit", sl.Err(err))
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// audit records a role change in the same transaction as the change itself.
+func (r *RoleStorage) audit(tx *sql.Tx, userId, actorId, organizationId string, action entity.RoleAuditAction, role entity.Role) error {
+	query, args, err := squirrel.
+		Insert(roleAuditTable).
+		Columns("user_id", "actor_id", "organization_id", "action", "role").
+		Values(userId, nullString(actorId), nullString(organizationId), action, role).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// scopeEq matches role assignments made in exactly the organization, an empty id matches global ones.
+func scopeEq(column, organizationId string) squirrel.Sqlizer {
+	if organizationId == "" {
+		return squirrel.Eq{column: nil}
+	}
+	return squirrel.Eq{column: organizationId}
+}
+
+// effectiveScope matches global role assignments together with those made in the organization.
+func effectiveScope(column, organizationId string) squirrel.Sqlizer {
+	if organizationId == "" {
+		return squirrel.Eq{column: nil}
+	}
+	return squirrel.Or{squirrel.Eq{column: nil}, squirrel.Eq{column: organizationId}}
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
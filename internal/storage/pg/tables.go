@@ -1,6 +1,15 @@
 package pg
 
 const (
-	usersTable string = "users"
-	roleTable  string = "roles"
+	usersTable           string = "users"
+	rolesTable           string = "roles"
+	userRolesTable       string = "user_roles"
+	permissionsTable     string = "permissions"
+	rolePermissionsTable string = "role_permissions"
+	roleAuditTable       string = "role_audit"
+	clientsTable         string = "clients"
+	organizationsTable   string = "organizations"
+	membersTable         string = "organization_members"
+	recoveryCodesTable   string = "recovery_codes"
+	passkeysTable        string = "passkeys"
 )
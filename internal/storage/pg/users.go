@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"time"
 
 	"mzhn/auth/internal/dto"
 	"mzhn/auth/internal/entity"
@@ -28,9 +29,27 @@ type UsersStorage struct {
 func (s *UsersStorage) Find(ctx context.Context, slug string) (*entity.User, error) {
 	log := s.logger.With(slog.String("user_id", slug)).With(slog.String("method", "Find"))
 
+	user, err := s.FindAny(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DisabledAt != nil {
+		log.Debug("user disabled")
+		return nil, authservice.ErrUserDisabled
+	}
+
+	return user, nil
+}
+
+// FindAny finds the user by id or email whether it is disabled or not.
+func (s *UsersStorage) FindAny(ctx context.Context, slug string) (*entity.User, error) {
+	log := s.logger.With(slog.String("user_id", slug)).With(slog.String("method", "FindAny"))
+
 	builder := squirrel.Select().
 		Columns("*").
 		From(usersTable).
+		Where(squirrel.Eq{"deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar)
 
 	if _, err := uuid.Parse(slug); err != nil {
@@ -106,6 +125,193 @@ func (s *UsersStorage) Save(ctx context.Context, user *dto.CreateUser) (*entity.
 	return newUser, nil
 }
 
+func (s *UsersStorage) Update(ctx context.Context, userId string, user *dto.UpdateUser) (*entity.User, error) {
+	log := s.logger.With(slog.String("user_id", userId), slog.String("method", "Update"))
+
+	builder := squirrel.
+		Update(usersTable).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": userId, "deleted_at": nil}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if user.FirstName != nil {
+		builder = builder.Set("first_name", user.FirstName)
+	}
+
+	if user.LastName != nil {
+		builder = builder.Set("last_name", user.LastName)
+	}
+
+	if user.MiddleName != nil {
+		builder = builder.Set("middle_name", user.MiddleName)
+	}
+
+	if user.Email != nil {
+		builder = builder.Set("email", user.Email)
+	}
+
+	if user.Password != nil {
+		builder = builder.Set("hashed_password", user.Password)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		builder = builder.Set("email_verified_at", user.EmailVerifiedAt)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	updated := new(entity.User)
+	if err = s.db.GetContext(ctx, updated, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, authservice.ErrUserNotFound
+		}
+		if e, ok := err.(pgx.PgError); ok {
+			log.Debug("pg error", sl.PgError(e))
+			if e.Code == "23505" {
+				return nil, storage.ErrUserAlreadyExists
+			}
+		}
+		log.Error("error updating user", sl.Err(err))
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// SetDisabled disables or enables the user, disabled users are not returned by Find.
+func (s *UsersStorage) SetDisabled(ctx context.Context, userId string, disabled bool) (*entity.User, error) {
+	log := s.logger.With(slog.String("user_id", userId), slog.String("method", "SetDisabled"))
+
+	if _, err := uuid.Parse(userId); err != nil {
+		return nil, authservice.ErrUserNotFound
+	}
+
+	disabledAt := squirrel.Expr("NULL")
+	if disabled {
+		disabledAt = squirrel.Expr("now()")
+	}
+
+	query, args, err := squirrel.
+		Update(usersTable).
+		Set("disabled_at", disabledAt).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": userId, "deleted_at": nil}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return nil, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	user := new(entity.User)
+	if err := s.db.GetContext(ctx, user, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, authservice.ErrUserNotFound
+		}
+		log.Error("error updating user", sl.Err(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Delete marks the user as deleted, the row is kept until Purge.
+func (s *UsersStorage) Delete(ctx context.Context, userId string) error {
+	log := s.logger.With(slog.String("user_id", userId), slog.String("method", "Delete"))
+
+	query, args, err := squirrel.
+		Update(usersTable).
+		Set("deleted_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": userId, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error deleting user", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return authservice.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Purge removes users deleted before the given time along with everything that references them.
+func (s *UsersStorage) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	log := s.logger.With(slog.String("method", "Purge"))
+
+	query, args, err := squirrel.
+		Delete(usersTable).
+		Where(squirrel.Lt{"deleted_at": deletedBefore}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return 0, err
+	}
+
+	log.Debug("query", slog.String("query", query), slog.Any("args", args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error purging users", sl.Err(err))
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// SetTotp replaces the encrypted TOTP secret of the user, nil values clear the columns.
+func (s *UsersStorage) SetTotp(ctx context.Context, userId string, secret []byte, enabledAt *time.Time) error {
+	log := s.logger.With(slog.String("user_id", userId), slog.String("method", "SetTotp"))
+
+	query, args, err := squirrel.
+		Update(usersTable).
+		Set("totp_secret", secret).
+		Set("totp_enabled_at", enabledAt).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": userId, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Error("error building query", sl.Err(err))
+		return err
+	}
+
+	log.Debug("query", slog.String("query", query))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Error("error updating totp", sl.Err(err))
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return authservice.ErrUserNotFound
+	}
+
+	return nil
+}
+
 func NewUserStorage(db *sqlx.DB) *UsersStorage {
 	return &UsersStorage{
 		db:     db,
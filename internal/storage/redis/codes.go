@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ authservice.AuthCodeStorage = (*AuthCodeStorage)(nil)
+
+type AuthCodeStorage struct {
+	db     *redis.Client
+	logger *slog.Logger
+}
+
+func authCodeKey(code string) string {
+	return fmt.Sprintf("auth_code:%s", hashToken(code))
+}
+
+func (s *AuthCodeStorage) Save(ctx context.Context, code string, authCode *entity.AuthCode, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "AuthCodeStorage.Save"), slog.String("client_id", authCode.ClientId))
+
+	log.Debug("Saving authorization code")
+
+	data, err := json.Marshal(authCode)
+	if err != nil {
+		log.Error("error marshaling authorization code", sl.Err(err))
+		return fmt.Errorf("failed saving authorization code %w", err)
+	}
+
+	if err := s.db.Set(ctx, authCodeKey(code), data, ttl).Err(); err != nil {
+		log.Error("error saving authorization code", sl.Err(err))
+		return fmt.Errorf("failed saving authorization code %w", err)
+	}
+
+	return nil
+}
+
+// Take returns the authorization code and deletes it, so every code is redeemed at most once.
+func (s *AuthCodeStorage) Take(ctx context.Context, code string) (*entity.AuthCode, error) {
+	log := s.logger.With(slog.String("method", "AuthCodeStorage.Take"))
+
+	log.Debug("Taking authorization code")
+
+	data, err := s.db.GetDel(ctx, authCodeKey(code)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrAuthCodeNotFound
+		}
+		log.Error("error taking authorization code", sl.Err(err))
+		return nil, fmt.Errorf("failed taking authorization code %w", err)
+	}
+
+	authCode := new(entity.AuthCode)
+	if err := json.Unmarshal(data, authCode); err != nil {
+		log.Error("error decoding authorization code", sl.Err(err))
+		return nil, fmt.Errorf("failed decoding authorization code %w", err)
+	}
+
+	return authCode, nil
+}
+
+func NewAuthCodeStorage(db *redis.Client) *AuthCodeStorage {
+	return &AuthCodeStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "AuthCodeStorage")),
+	}
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	securityEventsStream = "security_events"
+	securityEventsMaxLen = 10000
+)
+
+var _ authservice.EventsStorage = (*EventsStorage)(nil)
+
+// EventsStorage appends security events to a redis stream so that
+// alerting and audit consumers can subscribe to them.
+type EventsStorage struct {
+	db     *redis.Client
+	logger *slog.Logger
+}
+
+func (s *EventsStorage) Publish(ctx context.Context, event *entity.SecurityEvent) error {
+	log := s.logger.With(slog.String("method", "EventsStorage.Publish"), slog.String("type", string(event.Type)))
+
+	log.Debug("Publishing event")
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Error("error marshaling event", sl.Err(err))
+		return fmt.Errorf("failed publishing event %w", err)
+	}
+
+	if err := s.db.XAdd(ctx, &redis.XAddArgs{
+		Stream: securityEventsStream,
+		MaxLen: securityEventsMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"type":  string(event.Type),
+			"event": data,
+		},
+	}).Err(); err != nil {
+		log.Error("error publishing event", sl.Err(err))
+		return fmt.Errorf("failed publishing event %w", err)
+	}
+
+	return nil
+}
+
+func NewEventsStorage(db *redis.Client) *EventsStorage {
+	return &EventsStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "EventsStorage")),
+	}
+}
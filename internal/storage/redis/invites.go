@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/entity"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ authservice.InviteStorage = (*InviteStorage)(nil)
+
+type InviteStorage struct {
+	db     *redis.Client
+	logger *slog.Logger
+}
+
+func inviteKey(token string) string {
+	return fmt.Sprintf("invite:%s", hashToken(token))
+}
+
+func (s *InviteStorage) Save(ctx context.Context, token string, invite *entity.Invite, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "InviteStorage.Save"), slog.String("created_by", invite.CreatedBy))
+
+	log.Debug("Saving invite")
+
+	data, err := json.Marshal(invite)
+	if err != nil {
+		log.Error("error marshaling invite", sl.Err(err))
+		return fmt.Errorf("failed saving invite %w", err)
+	}
+
+	if err := s.db.Set(ctx, inviteKey(token), data, ttl).Err(); err != nil {
+		log.Error("error saving invite", sl.Err(err))
+		return fmt.Errorf("failed saving invite %w", err)
+	}
+
+	return nil
+}
+
+// Take returns the invite and deletes it, so every invite is redeemed at most once.
+func (s *InviteStorage) Take(ctx context.Context, token string) (*entity.Invite, error) {
+	log := s.logger.With(slog.String("method", "InviteStorage.Take"))
+
+	log.Debug("Taking invite")
+
+	data, err := s.db.GetDel(ctx, inviteKey(token)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrInviteNotFound
+		}
+		log.Error("error taking invite", sl.Err(err))
+		return nil, fmt.Errorf("failed taking invite %w", err)
+	}
+
+	invite := new(entity.Invite)
+	if err := json.Unmarshal(data, invite); err != nil {
+		log.Error("error decoding invite", sl.Err(err))
+		return nil, fmt.Errorf("failed decoding invite %w", err)
+	}
+
+	return invite, nil
+}
+
+func NewInviteStorage(db *redis.Client) *InviteStorage {
+	return &InviteStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "InviteStorage")),
+	}
+}
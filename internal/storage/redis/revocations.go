@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ authservice.RevocationStorage = (*RevocationStorage)(nil)
+
+// RevocationStorage keeps revoked access token ids and ended session ids until the tokens expire
+// and a per-user watermark revoking every token issued before it. The watermark is kept in milliseconds
+// like iat, rounded up so that tokens issued within the millisecond of the revocation are revoked as well.
+type RevocationStorage struct {
+	db     *redis.Client
+	cfg    *config.Config
+	logger *slog.Logger
+}
+
+func revokedTokenKey(tokenId string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenId)
+}
+
+func revokedBeforeKey(userId string) string {
+	return fmt.Sprintf("revoked_before:%s", userId)
+}
+
+func revokedSessionKey(sessionId string) string {
+	return fmt.Sprintf("revoked_session:%s", sessionId)
+}
+
+// watermark is the revocation time in milliseconds, rounded up to cover the whole millisecond.
+func watermark(at time.Time) int64 {
+	return (at.UnixMicro() + 999) / 1000
+}
+
+func issuedBefore(issuedAt time.Time, watermark int64) bool {
+	return issuedAt.UnixMilli() < watermark
+}
+
+func (s *RevocationStorage) Revoke(ctx context.Context, tokenId string, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "RevocationStorage.Revoke"), slog.String("token_id", tokenId))
+
+	log.Debug("Revoking token")
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.db.Set(ctx, revokedTokenKey(tokenId), 1, ttl).Err(); err != nil {
+		log.Error("error revoking token", sl.Err(err))
+		return fmt.Errorf("failed revoking token %w", err)
+	}
+
+	return nil
+}
+
+func (s *RevocationStorage) RevokeBefore(ctx context.Context, userId string, at time.Time) error {
+	log := s.logger.With(slog.String("method", "RevocationStorage.RevokeBefore"), slog.String("user_id", userId))
+
+	log.Debug("Revoking tokens", slog.Time("before", at))
+
+	// no token outlives the refresh ttl, so neither does the watermark
+	ttl := time.Duration(s.cfg.Jwt.RefreshTTL) * time.Minute
+	if err := s.db.Set(ctx, revokedBeforeKey(userId), watermark(at), ttl).Err(); err != nil {
+		log.Error("error revoking tokens", sl.Err(err))
+		return fmt.Errorf("failed revoking tokens %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession denies every access token of the session, ttl should cover the lifetime of the last one issued.
+func (s *RevocationStorage) RevokeSession(ctx context.Context, sessionId string, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "RevocationStorage.RevokeSession"), slog.String("session_id", sessionId))
+
+	log.Debug("Revoking session")
+
+	if sessionId == "" || ttl <= 0 {
+		return nil
+	}
+
+	if err := s.db.Set(ctx, revokedSessionKey(sessionId), 1, ttl).Err(); err != nil {
+		log.Error("error revoking session", sl.Err(err))
+		return fmt.Errorf("failed revoking session %w", err)
+	}
+
+	return nil
+}
+
+func (s *RevocationStorage) IsRevoked(ctx context.Context, userId, sessionId, tokenId string, issuedAt time.Time) (bool, error) {
+	log := s.logger.With(slog.String("method", "RevocationStorage.IsRevoked"), slog.String("user_id", userId), slog.String("session_id", sessionId), slog.String("token_id", tokenId))
+
+	values, err := s.db.MGet(ctx, revokedTokenKey(tokenId), revokedBeforeKey(userId), revokedSessionKey(sessionId)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Error("error checking revocation", sl.Err(err))
+		return false, fmt.Errorf("failed checking revocation %w", err)
+	}
+
+	if values[0] != nil {
+		log.Debug("token revoked")
+		return true, nil
+	}
+
+	if sessionId != "" && values[2] != nil {
+		log.Debug("session revoked")
+		return true, nil
+	}
+
+	if before, ok := values[1].(string); ok {
+		revokedAt, err := strconv.ParseInt(before, 10, 64)
+		if err != nil {
+			log.Error("invalid revocation watermark", sl.Err(err))
+			return false, err
+		}
+
+		if issuedBefore(issuedAt, revokedAt) {
+			log.Debug("token issued before watermark", slog.Int64("watermark", revokedAt))
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func NewRevocationStorage(db *redis.Client, cfg *config.Config) *RevocationStorage {
+	return &RevocationStorage{
+		db:     db,
+		cfg:    cfg,
+		logger: slog.Default().With(slog.String("struct", "RevocationStorage")),
+	}
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatermark(t *testing.T) {
+	revokedAt := time.Date(2024, 10, 1, 12, 0, 0, 500_400_000, time.UTC)
+	wm := watermark(revokedAt)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"earlier second", revokedAt.Add(-time.Second), true},
+		{"same second before", revokedAt.Add(-100 * time.Millisecond), true},
+		{"same millisecond before", revokedAt.Add(-200 * time.Microsecond), true},
+		{"same millisecond after", revokedAt.Add(300 * time.Microsecond), true},
+		{"next millisecond", revokedAt.Add(600 * time.Microsecond), false},
+		{"same second after", revokedAt.Add(100 * time.Millisecond), false},
+		{"later second", revokedAt.Add(time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// iat carries milliseconds
+			issuedAt := tt.issuedAt.Truncate(time.Millisecond)
+
+			if got := issuedBefore(issuedAt, wm); got != tt.revoked {
+				t.Fatalf("issuedBefore(%s, %d) = %v, want %v", issuedAt.Format(time.RFC3339Nano), wm, got, tt.revoked)
+			}
+		})
+	}
+}
+
+func TestWatermarkWholeMillisecond(t *testing.T) {
+	revokedAt := time.Date(2024, 10, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	if got, want := watermark(revokedAt), revokedAt.UnixMilli(); got != want {
+		t.Fatalf("watermark() = %d, want %d", got, want)
+	}
+}
@@ -2,13 +2,19 @@ package redis
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"mzhn/auth/internal/config"
+	"mzhn/auth/internal/entity"
 	"mzhn/auth/internal/lib/logger/sl"
 	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -21,45 +27,153 @@ type SessionsStorage struct {
 	logger *slog.Logger
 }
 
-func (s *SessionsStorage) Save(ctx context.Context, userId string, token string) error {
-	log := s.logger.With(slog.String("user_id", userId))
+// sessionRecord keeps the hash of the current refresh token of a session
+// together with the hash of the token it was rotated from.
+type sessionRecord struct {
+	entity.Session
+	TokenHash  string `json:"tokenHash"`
+	ParentHash string `json:"parentHash"`
+}
+
+// rotateAttempts bounds the retries of a rotation losing the optimistic lock to a concurrent write.
+const rotateAttempts = 5
+
+func sessionKey(sessionId string) string {
+	return fmt.Sprintf("session:%s", sessionId)
+}
+
+// sessionFamilyKey holds every already rotated refresh token of a session
+// (token hash -> parent token hash), used to detect refresh token reuse.
+func sessionFamilyKey(sessionId string) string {
+	return fmt.Sprintf("session_family:%s", sessionId)
+}
+
+func userSessionsKey(userId string) string {
+	return fmt.Sprintf("user_sessions:%s", userId)
+}
+
+func (s *SessionsStorage) ttl() time.Duration {
+	return time.Duration(s.cfg.Jwt.RefreshTTL) * time.Minute
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *SessionsStorage) Save(ctx context.Context, session *entity.Session, token string) error {
+	log := s.logger.With(slog.String("method", "SessionStorage.Save"), slog.String("session_id", session.Id), slog.String("user_id", session.UserId))
 
 	log.Debug("Saving session")
 
-	if stat := s.db.Set(ctx, userId, token, time.Duration(s.cfg.Jwt.RefreshTTL)*time.Minute); stat.Err() != nil {
-		log.Error("error saving session", sl.Err(stat.Err()))
-		return fmt.Errorf("failed saving session %w", stat.Err())
+	data, err := json.Marshal(&sessionRecord{Session: *session, TokenHash: hashToken(token)})
+	if err != nil {
+		log.Error("error marshaling session", sl.Err(err))
+		return fmt.Errorf("failed saving session %w", err)
+	}
+
+	pipe := s.db.TxPipeline()
+	pipe.Set(ctx, sessionKey(session.Id), data, s.ttl())
+	pipe.SAdd(ctx, userSessionsKey(session.UserId), session.Id)
+	pipe.Expire(ctx, userSessionsKey(session.UserId), s.ttl())
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error("error saving session", sl.Err(err))
+		return fmt.Errorf("failed saving session %w", err)
 	}
 
 	return nil
 }
 
-func (s *SessionsStorage) Check(ctx context.Context, userId, refreshToken string) error {
-	log := s.logger.With(slog.String("user_id", userId))
+func (s *SessionsStorage) Check(ctx context.Context, sessionId, refreshToken string) (*entity.Session, error) {
+	log := s.logger.With(slog.String("method", "SessionStorage.Check"), slog.String("session_id", sessionId))
 
 	log.Debug("Checking session")
 
-	stat := s.db.Get(ctx, userId)
-	if err := stat.Err(); err != nil {
+	record, err := s.find(ctx, s.db, sessionId)
+	if err != nil {
 		log.Error("error checking session", sl.Err(err))
-		return fmt.Errorf("failed checking session %w", err)
+		return nil, err
 	}
 
-	if stat.Val() != refreshToken {
-		log.Error("invalid session", slog.String("user_id", userId), slog.String("refresh_token", refreshToken), slog.String("session_token", stat.Val()))
-		return fmt.Errorf("invalid session")
+	if err := s.match(ctx, s.db, record, refreshToken); err != nil {
+		log.Error("invalid session", slog.String("user_id", record.UserId), sl.Err(err))
+		return nil, err
 	}
 
-	return nil
+	return &record.Session, nil
+}
+
+func (s *SessionsStorage) Rotate(ctx context.Context, session *entity.Session, oldToken, newToken string) error {
+	log := s.logger.With(slog.String("method", "SessionStorage.Rotate"), slog.String("session_id", session.Id), slog.String("user_id", session.UserId))
+
+	log.Debug("Rotating session")
+
+	key := sessionKey(session.Id)
+	rotate := func(tx *redis.Tx) error {
+		record, err := s.find(ctx, tx, session.Id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.match(ctx, tx, record, oldToken); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(&sessionRecord{
+			Session:    *session,
+			TokenHash:  hashToken(newToken),
+			ParentHash: record.TokenHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, s.ttl())
+			pipe.HSet(ctx, sessionFamilyKey(session.Id), record.TokenHash, record.ParentHash)
+			pipe.Expire(ctx, sessionFamilyKey(session.Id), s.ttl())
+			pipe.Expire(ctx, userSessionsKey(session.UserId), s.ttl())
+			return nil
+		})
+		return err
+	}
+
+	// a conflicting write is not reuse by itself, the retry tells whether the token was rotated meanwhile
+	for attempt := 0; attempt < rotateAttempts; attempt++ {
+		err := s.db.Watch(ctx, rotate, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			log.Debug("session changed concurrently, retrying", slog.Int("attempt", attempt))
+			continue
+		}
+		if err != nil {
+			log.Error("error rotating session", sl.Err(err))
+			return err
+		}
+
+		return nil
+	}
+
+	log.Error("error rotating session, too many concurrent changes")
+	return fmt.Errorf("failed rotating session %w", redis.TxFailedErr)
 }
 
-func (s *SessionsStorage) Delete(ctx context.Context, userId string) error {
-	log := s.logger.With(slog.String("method", "SessionStorage.Delete"))
+func (s *SessionsStorage) Delete(ctx context.Context, sessionId string) error {
+	log := s.logger.With(slog.String("method", "SessionStorage.Delete"), slog.String("session_id", sessionId))
 
 	log.Debug("Deleting session")
 
-	stat := s.db.Del(ctx, userId)
-	if err := stat.Err(); err != nil {
+	record, err := s.find(ctx, s.db, sessionId)
+	if err != nil {
+		log.Error("error deleting session", sl.Err(err))
+		return err
+	}
+
+	pipe := s.db.TxPipeline()
+	pipe.Del(ctx, sessionKey(sessionId), sessionFamilyKey(sessionId))
+	pipe.SRem(ctx, userSessionsKey(record.UserId), sessionId)
+
+	if _, err := pipe.Exec(ctx); err != nil {
 		log.Error("error deleting session", sl.Err(err))
 		return fmt.Errorf("failed deleting session %w", err)
 	}
@@ -67,10 +181,129 @@ func (s *SessionsStorage) Delete(ctx context.Context, userId string) error {
 	return nil
 }
 
+func (s *SessionsStorage) Find(ctx context.Context, sessionId string) (*entity.Session, error) {
+	log := s.logger.With(slog.String("method", "SessionStorage.Find"), slog.String("session_id", sessionId))
+
+	record, err := s.find(ctx, s.db, sessionId)
+	if err != nil {
+		log.Error("error finding session", sl.Err(err))
+		return nil, err
+	}
+
+	return &record.Session, nil
+}
+
+func (s *SessionsStorage) List(ctx context.Context, userId string) ([]entity.Session, error) {
+	log := s.logger.With(slog.String("method", "SessionStorage.List"), slog.String("user_id", userId))
+
+	log.Debug("Listing sessions")
+
+	ids, err := s.db.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err != nil {
+		log.Error("error listing sessions", sl.Err(err))
+		return nil, fmt.Errorf("failed listing sessions %w", err)
+	}
+
+	sessions := make([]entity.Session, 0, len(ids))
+	stale := make([]any, 0)
+
+	for _, id := range ids {
+		record, err := s.find(ctx, s.db, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrSessionNotFound) {
+				stale = append(stale, id)
+				continue
+			}
+			log.Error("error listing sessions", sl.Err(err))
+			return nil, err
+		}
+
+		sessions = append(sessions, record.Session)
+	}
+
+	if len(stale) > 0 {
+		log.Debug("removing expired sessions", slog.Any("ids", stale))
+		if err := s.db.SRem(ctx, userSessionsKey(userId), stale...).Err(); err != nil {
+			log.Warn("error removing expired sessions", sl.Err(err))
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionsStorage) DeleteAll(ctx context.Context, userId, exceptSessionId string) ([]string, error) {
+	log := s.logger.With(slog.String("method", "SessionStorage.DeleteAll"), slog.String("user_id", userId))
+
+	log.Debug("Deleting sessions", slog.String("except", exceptSessionId))
+
+	ids, err := s.db.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err != nil {
+		log.Error("error listing sessions", sl.Err(err))
+		return nil, fmt.Errorf("failed deleting sessions %w", err)
+	}
+
+	deleted := make([]string, 0, len(ids))
+
+	pipe := s.db.TxPipeline()
+	for _, id := range ids {
+		if id == exceptSessionId {
+			continue
+		}
+
+		pipe.Del(ctx, sessionKey(id), sessionFamilyKey(id))
+		pipe.SRem(ctx, userSessionsKey(userId), id)
+		deleted = append(deleted, id)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error("error deleting sessions", sl.Err(err))
+		return nil, fmt.Errorf("failed deleting sessions %w", err)
+	}
+
+	return deleted, nil
+}
+
+// match reports whether token is the current refresh token of the session,
+// telling an unknown token apart from one that has already been rotated.
+func (s *SessionsStorage) match(ctx context.Context, db redis.Cmdable, record *sessionRecord, token string) error {
+	hash := hashToken(token)
+	if record.TokenHash == hash {
+		return nil
+	}
+
+	rotated, err := db.HExists(ctx, sessionFamilyKey(record.Id), hash).Result()
+	if err != nil {
+		return fmt.Errorf("failed checking session family %w", err)
+	}
+
+	if rotated {
+		return storage.ErrSessionReused
+	}
+
+	return storage.ErrSessionInvalid
+}
+
+func (s *SessionsStorage) find(ctx context.Context, db redis.Cmdable, sessionId string) (*sessionRecord, error) {
+	data, err := db.Get(ctx, sessionKey(sessionId)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed getting session %w", err)
+	}
+
+	record := new(sessionRecord)
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("failed decoding session %w", err)
+	}
+
+	return record, nil
+}
+
 func NewSessionsStorage(db *redis.Client, cfg *config.Config) *SessionsStorage {
 	return &SessionsStorage{
 		db:     db,
 		cfg:    cfg,
-		logger: slog.Default().With(slog.String("struct", "UserStorage")),
+		logger: slog.Default().With(slog.String("struct", "SessionsStorage")),
 	}
 }
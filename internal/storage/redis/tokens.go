@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mzhn/auth/internal/lib/logger/sl"
+	"mzhn/auth/internal/services/authservice"
+	"mzhn/auth/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ authservice.OneTimeTokenStorage = (*OneTimeTokenStorage)(nil)
+
+type OneTimeTokenStorage struct {
+	db     *redis.Client
+	logger *slog.Logger
+}
+
+func oneTimeTokenKey(purpose, token string) string {
+	return fmt.Sprintf("token:%s:%s", purpose, hashToken(token))
+}
+
+func (s *OneTimeTokenStorage) Save(ctx context.Context, purpose, token, subject string, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "OneTimeTokenStorage.Save"), slog.String("purpose", purpose), slog.String("subject", subject))
+
+	log.Debug("Saving token")
+
+	if err := s.db.Set(ctx, oneTimeTokenKey(purpose, token), subject, ttl).Err(); err != nil {
+		log.Error("error saving token", sl.Err(err))
+		return fmt.Errorf("failed saving token %w", err)
+	}
+
+	return nil
+}
+
+// Take returns the subject of the token and deletes it, so every token is used at most once.
+func (s *OneTimeTokenStorage) Take(ctx context.Context, purpose, token string) (string, error) {
+	log := s.logger.With(slog.String("method", "OneTimeTokenStorage.Take"), slog.String("purpose", purpose))
+
+	log.Debug("Taking token")
+
+	subject, err := s.db.GetDel(ctx, oneTimeTokenKey(purpose, token)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", storage.ErrTokenNotFound
+		}
+		log.Error("error taking token", sl.Err(err))
+		return "", fmt.Errorf("failed taking token %w", err)
+	}
+
+	return subject, nil
+}
+
+func (s *OneTimeTokenStorage) Use(ctx context.Context, purpose, token string, ttl time.Duration) error {
+	log := s.logger.With(slog.String("method", "OneTimeTokenStorage.Use"), slog.String("purpose", purpose))
+
+	ok, err := s.db.SetNX(ctx, oneTimeTokenKey(purpose, token), "", ttl).Result()
+	if err != nil {
+		log.Error("error using token", sl.Err(err))
+		return fmt.Errorf("failed using token %w", err)
+	}
+
+	if !ok {
+		return storage.ErrTokenUsed
+	}
+
+	return nil
+}
+
+func NewOneTimeTokenStorage(db *redis.Client) *OneTimeTokenStorage {
+	return &OneTimeTokenStorage{
+		db:     db,
+		logger: slog.Default().With(slog.String("struct", "OneTimeTokenStorage")),
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS clients (
+  id VARCHAR PRIMARY KEY,
+  name VARCHAR NOT NULL,
+  hashed_secret VARCHAR,
+  redirect_uris VARCHAR NOT NULL DEFAULT '',
+  scopes VARCHAR NOT NULL DEFAULT '',
+  grant_types VARCHAR NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP
+);
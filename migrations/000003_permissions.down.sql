@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS role_permissions;
+
+DROP TABLE IF EXISTS permissions;
+
+CREATE TYPE ROLE AS ENUM ('admin', 'support', 'regular');
+
+DELETE FROM user_roles WHERE role NOT IN ('admin', 'support', 'regular');
+
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_pkey;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_user_id_fkey;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_role_fkey;
+
+DROP TABLE IF EXISTS roles;
+
+ALTER TABLE user_roles ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE user_roles ALTER COLUMN role TYPE ROLE USING role::ROLE;
+ALTER TABLE user_roles ALTER COLUMN role SET DEFAULT 'regular';
+
+ALTER TABLE user_roles RENAME TO roles;
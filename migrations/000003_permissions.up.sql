@@ -0,0 +1,36 @@
+ALTER TABLE roles RENAME TO user_roles;
+
+CREATE TABLE IF NOT EXISTS roles (
+  name VARCHAR PRIMARY KEY,
+  description VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP
+);
+
+INSERT INTO roles (name) VALUES ('admin'), ('support'), ('regular');
+
+DELETE FROM user_roles WHERE user_id IS NULL OR user_id NOT IN (SELECT id FROM users);
+
+DELETE FROM user_roles a USING user_roles b
+WHERE a.ctid < b.ctid AND a.user_id = b.user_id AND a.role = b.role;
+
+ALTER TABLE user_roles ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE user_roles ALTER COLUMN role TYPE VARCHAR USING role::TEXT;
+ALTER TABLE user_roles ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role);
+ALTER TABLE user_roles ADD FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE user_roles ADD FOREIGN KEY (role) REFERENCES roles (name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+DROP TYPE IF EXISTS ROLE;
+
+CREATE TABLE IF NOT EXISTS permissions (
+  name VARCHAR PRIMARY KEY,
+  description VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role VARCHAR NOT NULL REFERENCES roles (name) ON UPDATE CASCADE ON DELETE CASCADE,
+  permission VARCHAR NOT NULL REFERENCES permissions (name) ON UPDATE CASCADE ON DELETE CASCADE,
+  PRIMARY KEY (role, permission)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS role_audit (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  actor_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  action VARCHAR NOT NULL,
+  role VARCHAR NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS role_audit_user_id_idx ON role_audit (user_id, created_at);
@@ -0,0 +1,10 @@
+ALTER TABLE role_audit DROP COLUMN IF EXISTS organization_id;
+
+DELETE FROM user_roles WHERE organization_id IS NOT NULL;
+DROP INDEX IF EXISTS user_roles_scope_idx;
+ALTER TABLE user_roles DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role);
+
+DROP TABLE IF EXISTS organization_members;
+
+DROP TABLE IF EXISTS organizations;
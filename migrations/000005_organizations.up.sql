@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS organizations (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name VARCHAR NOT NULL,
+  slug VARCHAR UNIQUE NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON organization_members (user_id);
+
+-- roles without an organization are global
+ALTER TABLE user_roles ADD COLUMN organization_id UUID REFERENCES organizations (id) ON DELETE CASCADE;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS user_roles_scope_idx ON user_roles (user_id, role, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'));
+
+ALTER TABLE role_audit ADD COLUMN organization_id UUID REFERENCES organizations (id) ON DELETE CASCADE;
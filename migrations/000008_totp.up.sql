@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN totp_secret BYTEA;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS passkeys (
+  id BYTEA PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  public_key BYTEA NOT NULL,
+  sign_count BIGINT NOT NULL DEFAULT 0,
+  name VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMP
+);
+
+CREATE INDEX passkeys_user_idx ON passkeys (user_id);
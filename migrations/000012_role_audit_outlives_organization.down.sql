@@ -0,0 +1,3 @@
+DELETE FROM role_audit WHERE organization_id IS NOT NULL AND organization_id NOT IN (SELECT id FROM organizations);
+
+ALTER TABLE role_audit ADD CONSTRAINT role_audit_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+-- audit rows outlive the organization, its id is kept without a foreign key
+ALTER TABLE role_audit DROP CONSTRAINT IF EXISTS role_audit_organization_id_fkey;